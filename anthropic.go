@@ -0,0 +1,389 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// AnthropicBaseURL is the default base URL for the Anthropic API.
+	AnthropicBaseURL = "https://api.anthropic.com/v1"
+
+	// AnthropicVersion is the default Anthropic API version.
+	AnthropicVersion = "2023-06-01"
+
+	// anthropicMaxTokens is used if the request and the model have no max tokens, the API requires it.
+	anthropicMaxTokens = 4096
+)
+
+// Anthropic is a provider of the Anthropic Messages API.
+// Only chat completions without streaming are supported.
+// System messages are joined to the top-level system prompt.
+// PresencePenalty, FrequencyPenalty and LogitBias are ignored.
+type Anthropic struct {
+	BaseURL string
+	APIKey  string
+	Version string
+}
+
+// anthropicSource is a source of image or document content block.
+type anthropicSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+}
+
+// anthropicBlock is a message content block.
+type anthropicBlock struct {
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	Source    *anthropicSource `json:"source,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   string           `json:"content,omitempty"`
+}
+
+// anthropicMessage is a message of user or assistant.
+type anthropicMessage struct {
+	Role    Role             `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicTool is a tool definition.
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicToolChoice is a tool choice mode.
+type anthropicToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// anthropicMetadata is a request metadata.
+type anthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// anthropicRequest is a request of the Messages API.
+type anthropicRequest struct {
+	Model         Model                `json:"model"`
+	MaxTokens     uint                 `json:"max_tokens"`
+	System        string               `json:"system,omitempty"`
+	Messages      []anthropicMessage   `json:"messages"`
+	Temperature   *float32             `json:"temperature,omitempty"`
+	TopP          *float32             `json:"top_p,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Tools         []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Metadata      *anthropicMetadata   `json:"metadata,omitempty"`
+}
+
+// anthropicUsage is a tokens usage of the response.
+type anthropicUsage struct {
+	InputTokens              uint `json:"input_tokens"`
+	OutputTokens             uint `json:"output_tokens"`
+	CacheCreationInputTokens uint `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     uint `json:"cache_read_input_tokens"`
+}
+
+// anthropicResponse is a response of the Messages API.
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Role       string           `json:"role"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      anthropicUsage   `json:"usage"`
+}
+
+// anthropicFinishReasons maps stop reasons to finish reasons, unknown reasons are FinishReasonStop.
+var anthropicFinishReasons = map[string]FinishReason{
+	"max_tokens": FinishReasonLength,
+	"tool_use":   FinishReasonToolCalls,
+	"refusal":    FinishReasonContentFilter,
+}
+
+// URL returns the Messages API URL for the chat completion path.
+func (a *Anthropic) URL(path string, _ Model) (string, error) {
+	if path != completionsPath {
+		return "", errors.Join(ErrRequiredParam, fmt.Errorf("path %q is not supported by anthropic provider", path))
+	}
+
+	baseURL := a.BaseURL
+	if baseURL == "" {
+		baseURL = AnthropicBaseURL
+	}
+
+	return strings.TrimRight(baseURL, "/") + "/messages", nil
+}
+
+// Authorize sets API key and version headers.
+func (a *Anthropic) Authorize(_ context.Context, header http.Header) error {
+	if a.APIKey == "" {
+		return fmt.Errorf("anthropic API key must not be empty")
+	}
+
+	version := a.Version
+	if version == "" {
+		version = AnthropicVersion
+	}
+
+	header.Set("x-api-key", a.APIKey)
+	header.Set("anthropic-version", version)
+
+	return nil
+}
+
+// EncodeCompletion converts the chat completion request to the Messages API request.
+func (a *Anthropic) EncodeCompletion(r *CompletionRequest) ([]byte, error) {
+	if r.N != nil && *r.N > 1 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("anthropic provider supports only one choice"))
+	}
+
+	if r.ResponseFormat != nil && r.ResponseFormat.Type != ResponseFormatText {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("response format is not supported by anthropic provider"))
+	}
+
+	if r.ReasoningEffort != "" || r.Verbosity != "" {
+		return nil, errors.Join(
+			ErrRequiredParam, fmt.Errorf("reasoning effort and verbosity are not supported by anthropic provider"),
+		)
+	}
+
+	request := anthropicRequest{
+		Model:       r.Model,
+		MaxTokens:   r.MaxTokens,
+		Temperature: r.Temperature,
+		TopP:        r.TopP,
+	}
+
+	if request.MaxTokens == 0 {
+		request.MaxTokens = anthropicMaxTokens
+
+		if limit, ok := r.Model.maxTokens(); ok {
+			request.MaxTokens = min(limit, anthropicMaxTokens)
+		}
+	}
+
+	if r.Stop != nil {
+		request.StopSequences = *r.Stop
+	}
+
+	if r.User != "" {
+		request.Metadata = &anthropicMetadata{UserID: r.User}
+	}
+
+	var system []string
+	for i := range r.Messages {
+		m := &r.Messages[i]
+
+		if m.Role.isInstruction() {
+			system = append(system, messageContent(m))
+			continue
+		}
+
+		message, err := anthropicEncodeMessage(m)
+		if err != nil {
+			return nil, err
+		}
+
+		// consecutive messages of the same role are merged, for example, several tool results
+		if n := len(request.Messages); n > 0 && request.Messages[n-1].Role == message.Role {
+			request.Messages[n-1].Content = append(request.Messages[n-1].Content, message.Content...)
+		} else {
+			request.Messages = append(request.Messages, message)
+		}
+	}
+
+	request.System = strings.Join(system, "\n\n")
+	request.Tools, request.ToolChoice = anthropicEncodeTools(r)
+
+	data, err := json.Marshal(&request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anthropic request: %w", err)
+	}
+
+	return data, nil
+}
+
+// anthropicEncodeMessage converts the message to user or assistant message with content blocks.
+func anthropicEncodeMessage(m *Message) (anthropicMessage, error) {
+	switch m.Role {
+	case RoleTool:
+		block := anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: messageContent(m)}
+		return anthropicMessage{Role: RoleUser, Content: []anthropicBlock{block}}, nil
+	case RoleUser, RoleAssistant:
+	default:
+		return anthropicMessage{}, errors.Join(
+			ErrRequiredParam, fmt.Errorf("role %q is not supported by anthropic provider", m.Role),
+		)
+	}
+
+	message := anthropicMessage{Role: m.Role}
+
+	if m.Content != "" && len(m.Parts) == 0 {
+		message.Content = append(message.Content, anthropicBlock{Type: "text", Text: m.Content})
+	}
+
+	for i := range m.Parts {
+		block, err := anthropicEncodePart(&m.Parts[i])
+		if err != nil {
+			return anthropicMessage{}, err
+		}
+
+		message.Content = append(message.Content, block)
+	}
+
+	for _, call := range m.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+
+		message.Content = append(
+			message.Content,
+			anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: input},
+		)
+	}
+
+	return message, nil
+}
+
+// anthropicEncodePart converts the content part to a content block.
+func anthropicEncodePart(part *ContentPart) (anthropicBlock, error) {
+	switch {
+	case part.Type == ContentPartText:
+		return anthropicBlock{Type: "text", Text: part.Text}, nil
+	case part.Type == ContentPartImageURL && part.ImageURL != nil:
+		return anthropicBlock{Type: "image", Source: anthropicEncodeSource(part.ImageURL.URL)}, nil
+	case part.Type == ContentPartFile && part.File != nil:
+		source := &anthropicSource{Type: "file", FileID: part.File.FileID}
+		if part.File.FileData != "" {
+			source = anthropicEncodeSource(part.File.FileData)
+		}
+
+		return anthropicBlock{Type: "document", Source: source}, nil
+	}
+
+	return anthropicBlock{}, errors.Join(
+		ErrRequiredParam, fmt.Errorf("content part %q is not supported by anthropic provider", part.Type),
+	)
+}
+
+// anthropicEncodeSource returns base64 source for data URL or URL source otherwise.
+func anthropicEncodeSource(url string) *anthropicSource {
+	if mimeType, data, ok := parseDataURL(url); ok {
+		return &anthropicSource{Type: "base64", MediaType: mimeType, Data: data}
+	}
+
+	return &anthropicSource{Type: "url", URL: url}
+}
+
+// anthropicEncodeTools converts function tools and the tool choice.
+func anthropicEncodeTools(r *CompletionRequest) ([]anthropicTool, *anthropicToolChoice) {
+	if len(r.Tools) == 0 {
+		return nil, nil
+	}
+
+	tools := make([]anthropicTool, 0, len(r.Tools))
+	for _, tool := range r.Tools {
+		schema := tool.Function.Parameters
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object"}`)
+		}
+
+		tools = append(tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	choice := &anthropicToolChoice{Type: "auto"}
+	if r.ToolChoice != nil {
+		switch {
+		case r.ToolChoice.Function != "":
+			choice = &anthropicToolChoice{Type: "tool", Name: r.ToolChoice.Function}
+		case r.ToolChoice.Mode == ToolChoiceNone:
+			choice.Type = "none"
+		case r.ToolChoice.Mode == ToolChoiceRequired:
+			choice.Type = "any"
+		}
+	}
+
+	if r.ParallelToolCalls != nil && !*r.ParallelToolCalls && choice.Type != "none" {
+		choice.DisableParallelToolUse = true
+	}
+
+	return tools, choice
+}
+
+// DecodeCompletion converts the Messages API response to the chat completion response.
+func (a *Anthropic) DecodeCompletion(body io.Reader) (*CompletionResponse, error) {
+	var response anthropicResponse
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal anthropic response: %w", err)
+	}
+
+	var (
+		message = Message{Role: RoleAssistant}
+		texts   []string
+	)
+
+	for _, block := range response.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, ToolCall{
+				ID:       block.ID,
+				Type:     ToolTypeFunction,
+				Function: FunctionCall{Name: block.Name, Arguments: string(block.Input)},
+			})
+		}
+	}
+
+	message.Content = strings.Join(texts, "")
+
+	finishReason, ok := anthropicFinishReasons[response.StopReason]
+	if !ok {
+		finishReason = FinishReasonStop
+	}
+
+	if finishReason == FinishReasonContentFilter {
+		message.Refusal = message.Content
+	}
+
+	usage := response.Usage
+	prompt := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+
+	return &CompletionResponse{
+		ID:      response.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Choices: []Choice{{Message: message, FinishReason: finishReason}},
+		Usage: Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: usage.OutputTokens,
+			TotalTokens:      prompt + usage.OutputTokens,
+			PromptTokensDetails: PromptTokensDetails{
+				CachedTokens: usage.CacheReadInputTokens,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,292 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicEncodeCompletion(t *testing.T) {
+	var (
+		temperature = float32(0.5)
+		parallel    = false
+		stop        = []string{"END"}
+	)
+
+	request := &CompletionRequest{
+		Model: ModelClaudeSonnet4,
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleSystem, Parts: []ContentPart{TextPart("Use tools.")}},
+			{Role: RoleUser, Parts: []ContentPart{
+				TextPart("What is here?"),
+				ImageURLPart("data:image/png;base64,iVBORw0K", ImageDetailLow),
+				ImageURLPart("https://localhost/a.png", ""),
+			}},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{
+				{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "call_2", Type: ToolTypeFunction, Function: FunctionCall{Name: "time"}},
+			}},
+			{Role: RoleTool, ToolCallID: "call_1", Content: "sunny"},
+			{Role: RoleTool, ToolCallID: "call_2", Content: "noon"},
+		},
+		Temperature:       &temperature,
+		Stop:              &stop,
+		User:              "user-1",
+		ParallelToolCalls: &parallel,
+		Tools: []Tool{
+			{Type: ToolTypeFunction, Function: Function{Name: "weather", Parameters: json.RawMessage(`{"type":"object"}`)}},
+			{Type: ToolTypeFunction, Function: Function{Name: "time", Description: "current time"}},
+		},
+		ToolChoice: &ToolChoice{Mode: ToolChoiceRequired},
+	}
+
+	data, err := (&Anthropic{}).EncodeCompletion(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"model":"claude-sonnet-4-0","max_tokens":4096,"system":"Be brief.\n\nUse tools.",` +
+		`"messages":[` +
+		`{"role":"user","content":[{"type":"text","text":"What is here?"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0K"}},` +
+		`{"type":"image","source":{"type":"url","url":"https://localhost/a.png"}}]},` +
+		`{"role":"assistant","content":[` +
+		`{"type":"tool_use","id":"call_1","name":"weather","input":{"city":"Paris"}},` +
+		`{"type":"tool_use","id":"call_2","name":"time","input":{}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":"sunny"},` +
+		`{"type":"tool_result","tool_use_id":"call_2","content":"noon"}]}],` +
+		`"temperature":0.5,"stop_sequences":["END"],` +
+		`"tools":[{"name":"weather","input_schema":{"type":"object"}},` +
+		`{"name":"time","description":"current time","input_schema":{"type":"object"}}],` +
+		`"tool_choice":{"type":"any","disable_parallel_tool_use":true},"metadata":{"user_id":"user-1"}}`
+
+	if s := string(data); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+}
+
+func TestAnthropicEncodeDecodedMessage(t *testing.T) {
+	var message Message
+
+	data := `{"role":"user","content":[{"type":"text","text":"Hello"},` +
+		`{"type":"image_url","image_url":{"url":"https://localhost/a.png"}}]}`
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request := &CompletionRequest{Model: ModelClaude35Haiku, Messages: []Message{message}, MaxTokens: 10}
+
+	body, err := (&Anthropic{}).EncodeCompletion(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"model":"claude-3-5-haiku-latest","max_tokens":10,"messages":[{"role":"user","content":[` +
+		`{"type":"text","text":"Hello"},{"type":"image","source":{"type":"url","url":"https://localhost/a.png"}}]}]}`
+
+	if s := string(body); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+}
+
+func TestAnthropicEncodeCompletionFailed(t *testing.T) {
+	n := uint(2)
+	messages := []Message{{Role: RoleUser, Content: "Hello"}}
+
+	testCases := []struct {
+		name    string
+		request CompletionRequest
+	}{
+		{
+			name:    "several choices",
+			request: CompletionRequest{Model: ModelClaude35Haiku, Messages: messages, N: &n},
+		},
+		{
+			name: "response format",
+			request: CompletionRequest{
+				Model:          ModelClaude35Haiku,
+				Messages:       messages,
+				ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject},
+			},
+		},
+		{
+			name: "audio",
+			request: CompletionRequest{
+				Model:    ModelClaude35Haiku,
+				Messages: []Message{{Role: RoleUser, Parts: []ContentPart{InputAudioPart([]byte("abc"), "wav")}}},
+			},
+		},
+		{
+			name: "role",
+			request: CompletionRequest{
+				Model:    ModelClaude35Haiku,
+				Messages: []Message{{Role: "unknown", Content: "Hello"}},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := (&Anthropic{}).EncodeCompletion(&tc.request); !errors.Is(err, ErrRequiredParam) {
+				t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+			}
+		})
+	}
+}
+
+func TestAnthropicDecodeCompletion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected Choice
+		usage    Usage
+	}{
+		{
+			name: "text",
+			body: `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-0",` +
+				`"content":[{"type":"text","text":"Hello"},{"type":"text","text":" world"}],` +
+				`"stop_reason":"max_tokens","usage":{"input_tokens":10,"output_tokens":5,` +
+				`"cache_read_input_tokens":3,"cache_creation_input_tokens":2}}`,
+			expected: Choice{Message: Message{Role: RoleAssistant, Content: "Hello world"}, FinishReason: FinishReasonLength},
+			usage: Usage{
+				PromptTokens:        15,
+				CompletionTokens:    5,
+				TotalTokens:         20,
+				PromptTokensDetails: PromptTokensDetails{CachedTokens: 3},
+			},
+		},
+		{
+			name: "tool use",
+			body: `{"id":"msg_2","content":[{"type":"thinking","thinking":"..."},` +
+				`{"type":"tool_use","id":"toolu_1","name":"weather","input":{"city":"Paris"}}],` +
+				`"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`,
+			expected: Choice{
+				Message: Message{Role: RoleAssistant, ToolCalls: []ToolCall{{
+					ID:       "toolu_1",
+					Type:     ToolTypeFunction,
+					Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+				}}},
+				FinishReason: FinishReasonToolCalls,
+			},
+			usage: Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		},
+		{
+			name: "refusal",
+			body: `{"id":"msg_3","content":[{"type":"text","text":"No"}],"stop_reason":"refusal"}`,
+			expected: Choice{
+				Message:      Message{Role: RoleAssistant, Content: "No", Refusal: "No"},
+				FinishReason: FinishReasonContentFilter,
+			},
+		},
+		{
+			name:     "end turn",
+			body:     `{"id":"msg_4","content":[{"type":"text","text":"Done"}],"stop_reason":"end_turn"}`,
+			expected: Choice{Message: Message{Role: RoleAssistant, Content: "Done"}, FinishReason: FinishReasonStop},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := (&Anthropic{}).DecodeCompletion(strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(response.Choices) != 1 || !reflect.DeepEqual(response.Choices[0], tc.expected) {
+				t.Errorf("expected %#v, got %#v", tc.expected, response.Choices)
+			}
+
+			if response.Usage != tc.usage {
+				t.Errorf("expected %v, got %v", tc.usage, response.Usage)
+			}
+		})
+	}
+
+	if _, err := (&Anthropic{}).DecodeCompletion(strings.NewReader("{")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestAnthropicClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		expectedHeaders := map[string]string{
+			"x-api-key":         "test-key",
+			"anthropic-version": AnthropicVersion,
+			"Authorization":     "",
+		}
+
+		for key, expected := range expectedHeaders {
+			if value := r.Header.Get(key); value != expected {
+				t.Errorf("failed %s header: %q", key, value)
+			}
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if s := string(data); strings.Contains(s, "Error") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, err = fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`)
+		} else {
+			_, err = fmt.Fprint(w, `{"id":"msg_1","content":[{"type":"text","text":"Hello"}],`+
+				`"stop_reason":"max_tokens","usage":{"input_tokens":4,"output_tokens":6}}`)
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	provider := &Anthropic{BaseURL: s.URL + "/v1/", APIKey: "test-key"}
+	client := NewClient(WithProvider(provider), WithHTTPClient(s.Client()), WithStopMarker("..."))
+	ctx := context.Background()
+
+	request := &CompletionRequest{Model: ModelClaude35Haiku, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	response, err := client.Completion(ctx, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := response.String(); s != "Hello..." {
+		t.Errorf("unexpected response %q", s)
+	}
+
+	if info := response.UsageInfo(); info != "prompt tokens: 4, completion tokens: 6, total tokens: 10" {
+		t.Errorf("unexpected usage %q", info)
+	}
+
+	request.Messages[0].Content = "Error"
+	if _, err = client.Completion(ctx, request); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Response.E.Type != "invalid_request_error" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err = client.CompletionStream(ctx, request); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+
+	if _, err = client.Image(ctx, &ImageRequest{Model: ModelDalle3, Prompt: "cat"}); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
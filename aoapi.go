@@ -28,6 +28,10 @@ var (
 	ErrResponse = errors.New("failed response")
 
 	// TokenLimits is a map of AI model names and the maximum number of tokens for them.
+	// It is used to initialize the models registry, limits changed or added after the package initialization
+	// are still used and take precedence over the registry ones.
+	//
+	// Deprecated: use LookupModel and RegisterModel instead.
 	TokenLimits = map[Model]uint{
 		ModelGPT35Turbo:       4096,    // total input+output is 16k
 		ModelGPT4:             8192,    // total input+output is 8k
@@ -55,10 +59,18 @@ var (
 )
 
 // Message is a struct of user message.
+// ToolCalls are set for assistant messages, ToolCallID is required for tool messages.
+// If Parts is not empty, it is sent instead of Content.
+// ReasoningContent is returned by DeepSeek reasoner model, it is not sent back in requests.
 type Message struct {
-	Role    Role   `json:"role"`
-	Content string `json:"content"`
-	Name    string `json:"name,omitempty"`
+	Role             Role          `json:"role"`
+	Content          string        `json:"content"`
+	Parts            []ContentPart `json:"-"`
+	Name             string        `json:"name,omitempty"`
+	ToolCalls        []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID       string        `json:"tool_call_id,omitempty"`
+	Refusal          string        `json:"refusal,omitempty"`
+	ReasoningContent string        `json:"reasoning_content,omitempty"`
 }
 
 // Choice is a struct of response choice.
@@ -69,10 +81,15 @@ type Choice struct {
 }
 
 // Usage is additional information about the response limit usage.
+// Prompt cache fields are set only by DeepSeek API.
 type Usage struct {
-	PromptTokens     uint `json:"prompt_tokens"`
-	CompletionTokens uint `json:"completion_tokens"`
-	TotalTokens      uint `json:"total_tokens"`
+	PromptTokens            uint                    `json:"prompt_tokens"`
+	CompletionTokens        uint                    `json:"completion_tokens"`
+	TotalTokens             uint                    `json:"total_tokens"`
+	PromptTokensDetails     PromptTokensDetails     `json:"prompt_tokens_details,omitzero"`
+	CompletionTokensDetails CompletionTokensDetails `json:"completion_tokens_details,omitzero"`
+	PromptCacheHitTokens    uint                    `json:"prompt_cache_hit_tokens,omitempty"`
+	PromptCacheMissTokens   uint                    `json:"prompt_cache_miss_tokens,omitempty"`
 }
 
 // CompletionRequest is a struct of request.
@@ -80,58 +97,112 @@ type CompletionRequest struct {
 	Model    Model     `json:"model"`
 	Messages []Message `json:"messages"`
 	// optional
-	MaxTokens        uint                `json:"max_completion_tokens,omitempty"`
-	User             string              `json:"user,omitempty"`
-	Temperature      *float32            `json:"temperature,omitempty"`
-	TopP             *float32            `json:"top_p,omitempty"`
-	N                *uint               `json:"n,omitempty"`
-	Stream           *bool               `json:"stream,omitempty"`
-	Stop             *[]string           `json:"stop,omitempty"`
-	PresencePenalty  *float32            `json:"presence_penalty,omitempty"`
-	FrequencyPenalty *float32            `json:"frequency_penalty,omitempty"`
-	LogitBias        *map[string]float32 `json:"logit_bias,omitempty"`
+	MaxTokens         uint                `json:"max_completion_tokens,omitempty"`
+	User              string              `json:"user,omitempty"`
+	Temperature       *float32            `json:"temperature,omitempty"`
+	TopP              *float32            `json:"top_p,omitempty"`
+	N                 *uint               `json:"n,omitempty"`
+	Stream            *bool               `json:"stream,omitempty"`
+	StreamOptions     *StreamOptions      `json:"stream_options,omitempty"`
+	Stop              *[]string           `json:"stop,omitempty"`
+	PresencePenalty   *float32            `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  *float32            `json:"frequency_penalty,omitempty"`
+	LogitBias         *map[string]float32 `json:"logit_bias,omitempty"`
+	Tools             []Tool              `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice         `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool               `json:"parallel_tool_calls,omitempty"`
+	ResponseFormat    *ResponseFormat     `json:"response_format,omitempty"`
+	ReasoningEffort   ReasoningEffort     `json:"reasoning_effort,omitempty"`
+	Verbosity         Verbosity           `json:"verbosity,omitempty"`
+	// Extra parameters are added to the request body, for example, options of OpenAI compatible servers.
+	// They can not override other request fields.
+	Extra map[string]any `json:"-"`
 }
 
-func (c *CompletionRequest) marshal() (io.Reader, error) {
+// validate checks the request parameters.
+func (c *CompletionRequest) validate() error {
 	if c.Model == "" {
-		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model must not be empty"))
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model must not be empty"))
 	}
 
 	if len(c.Messages) == 0 {
-		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("messages must not be empty"))
+		return errors.Join(ErrRequiredParam, fmt.Errorf("messages must not be empty"))
 	}
 
-	if (c.MaxTokens > 0) && (c.MaxTokens > TokenLimits[c.Model]) {
-		return nil, errors.Join(
+	if limit, ok := c.Model.maxTokens(); ok && (c.MaxTokens > limit) {
+		return errors.Join(
 			ErrRequiredParam,
-			fmt.Errorf("max tokens limit is %d, but gotten %d", TokenLimits[c.Model], c.MaxTokens),
+			fmt.Errorf("max tokens limit is %d, but gotten %d", limit, c.MaxTokens),
 		)
 	}
 
-	data, err := json.Marshal(c)
+	if err := c.checkContextWindow(); err != nil {
+		return err
+	}
+
+	if err := c.validateTools(); err != nil {
+		return err
+	}
+
+	if err := c.validateReasoning(); err != nil {
+		return err
+	}
+
+	if c.ResponseFormat != nil {
+		return c.ResponseFormat.validate()
+	}
+
+	return nil
+}
+
+func (c *CompletionRequest) marshal() (io.Reader, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	var request any = c.withoutSampling()
+	if c.Model.isDeepSeek() {
+		request = c.withoutSampling().deepSeek()
+	}
+
+	data, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	if data, err = mergeExtra(data, c.Extra); err != nil {
+		return nil, err
+	}
+
 	return bytes.NewReader(data), nil
 }
 
 func (c *CompletionRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
-	body, err := c.marshal()
+	var (
+		body io.Reader
+		err  error
+	)
+
+	switch provider := auth.Provider.(type) {
+	case CompletionAdapter:
+		body, err = encodeCompletion(provider, c)
+	case extraProvider:
+		body, err = c.withExtra(provider.extra()).marshal()
+	default:
+		body, err = c.marshal()
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.URL, body)
+	req, err := newRequest(ctx, auth, c.Model, body, "application/json")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", auth.Bearer))
-
-	if auth.Organization != "" {
-		req.Header.Set("OpenAI-Organization", auth.Organization)
+	if c.isStream() {
+		req.Header.Set("Accept", "text/event-stream")
 	}
 
 	return req, nil
@@ -146,6 +217,7 @@ type CompletionResponse struct {
 	Usage      Usage     `json:"usage"`
 	CreatedTs  time.Time `json:"-"`
 	stopMarker string
+	model      Model
 }
 
 func (r *CompletionResponse) build(body io.Reader) error {
@@ -181,26 +253,11 @@ func (r *CompletionResponse) String() string {
 
 // UsageInfo returns API tokens usage information.
 func (r *CompletionResponse) UsageInfo() string {
-	return fmt.Sprintf("prompt tokens: %d, completion tokens: %d, total tokens: %d",
-		r.Usage.PromptTokens, r.Usage.CompletionTokens, r.Usage.TotalTokens,
-	)
+	return r.Usage.String()
 }
 
 // Completion sends a request to the API and returns a response.
+// If the request has enabled Stream, the response is assembled from the received chunks.
 func Completion(ctx context.Context, client *http.Client, r *CompletionRequest, p Params) (*CompletionResponse, error) {
-	body, err := commonRequest(ctx, client, r, p)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		_ = body.Close()
-	}()
-
-	response := &CompletionResponse{stopMarker: p.StopMarker}
-	if err = response.build(body); err != nil {
-		return nil, err
-	}
-
-	return response, nil
+	return newParamsClient(client, p).Completion(ctx, r)
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -32,16 +33,7 @@ func compareCompletionResponses(a, b CompletionResponse) bool {
 	}
 
 	// compare Choices
-	if len(a.Choices) != len(b.Choices) {
-		return false
-	}
-	for i := range a.Choices {
-		if a.Choices[i] != b.Choices[i] {
-			return false
-		}
-	}
-
-	return true
+	return reflect.DeepEqual(a.Choices, b.Choices)
 }
 
 func TestCompletionRequestMarshal(t *testing.T) {
@@ -540,7 +532,7 @@ func TestCompletionRequestMaxTokens(t *testing.T) {
 		{
 			name:      "valid_with_limit",
 			model:     ModelGPT4,
-			maxTokens: TokenLimits[ModelGPT4] - 1,
+			maxTokens: TokenLimits[ModelGPT4] - 16, // the prompt must fit the context window
 		},
 		{
 			name:      "failed",
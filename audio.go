@@ -0,0 +1,419 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// audioUploadMaxSize is the maximum size of uploaded audio files.
+const audioUploadMaxSize = 25 << 20
+
+// audioContentTypes are content types of supported audio formats by file extensions.
+var audioContentTypes = map[string]string{
+	".flac": "audio/flac",
+	".mp3":  "audio/mpeg",
+	".mp4":  "audio/mp4",
+	".mpeg": "audio/mpeg",
+	".mpga": "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+}
+
+// TranscriptionFormat is a type of transcription response format.
+type TranscriptionFormat string
+
+// Transcription response formats, gpt-4o transcribe models support only json and text ones.
+const (
+	TranscriptionJSON        TranscriptionFormat = "json"
+	TranscriptionVerboseJSON TranscriptionFormat = "verbose_json"
+	TranscriptionSRT         TranscriptionFormat = "srt"
+	TranscriptionVTT         TranscriptionFormat = "vtt"
+	TranscriptionText        TranscriptionFormat = "text"
+)
+
+// TimestampGranularity is a type of transcription timestamps detail level.
+type TimestampGranularity string
+
+// Timestamp granularities, they require verbose_json response format.
+const (
+	TimestampWord    TimestampGranularity = "word"
+	TimestampSegment TimestampGranularity = "segment"
+)
+
+// AudioFile is an uploaded audio file, Name extension defines the audio format,
+// for example, "meeting.mp3". Supported formats are flac, mp3, mp4, mpeg, mpga, m4a, ogg, wav and webm.
+// Reader is rewound to the start before sending if it implements io.Seeker,
+// otherwise the request is not retried, because its data can not be read again.
+type AudioFile struct {
+	Name   string
+	Reader io.Reader
+}
+
+// upload checks the audio file and returns the form file.
+func (f *AudioFile) upload() (formFile, error) {
+	contentType, ok := audioContentTypes[strings.ToLower(filepath.Ext(f.Name))]
+	if !ok {
+		return formFile{}, errors.Join(ErrRequiredParam, fmt.Errorf("audio file %q format is not supported", f.Name))
+	}
+
+	if f.Reader == nil {
+		return formFile{}, errors.Join(ErrRequiredParam, fmt.Errorf("audio file reader must not be nil"))
+	}
+
+	if err := rewind(f.Reader); err != nil {
+		return formFile{}, err
+	}
+
+	if size, ok := readerSize(f.Reader); ok && size > audioUploadMaxSize {
+		return formFile{}, errors.Join(
+			ErrRequiredParam, fmt.Errorf("audio file size %d is larger than %d bytes", size, audioUploadMaxSize),
+		)
+	}
+
+	return formFile{
+		field:       "file",
+		name:        filepath.Base(f.Name),
+		contentType: contentType,
+		reader:      f.Reader,
+		maxSize:     audioUploadMaxSize,
+	}, nil
+}
+
+// TranscriptionRequest is a request of audio transcriptions API.
+// Language is an ISO-639-1 code of the input audio, Prompt is a text to guide the model style.
+type TranscriptionRequest struct {
+	Model                  Model
+	File                   AudioFile
+	Language               string
+	Prompt                 string
+	ResponseFormat         TranscriptionFormat
+	Temperature            *float32
+	TimestampGranularities []TimestampGranularity
+}
+
+// TranslationRequest is a request of audio translations API, the audio is translated into English.
+// Only whisper-1 model is supported.
+type TranslationRequest struct {
+	Model          Model
+	File           AudioFile
+	Prompt         string
+	ResponseFormat TranscriptionFormat
+	Temperature    *float32
+}
+
+// audioFields returns the common form fields of audio requests.
+func audioFields(model Model, prompt string, format TranscriptionFormat, temperature *float32) []formField {
+	fields := []formField{{name: "model", value: string(model)}}
+
+	if prompt != "" {
+		fields = append(fields, formField{name: "prompt", value: prompt})
+	}
+
+	if format != "" {
+		fields = append(fields, formField{name: "response_format", value: string(format)})
+	}
+
+	if temperature != nil {
+		fields = append(fields, formField{
+			name:  "temperature",
+			value: strconv.FormatFloat(float64(*temperature), 'f', -1, 32),
+		})
+	}
+
+	return fields
+}
+
+// validateAudio checks the common parameters of audio requests.
+func validateAudio(model Model, format TranscriptionFormat, temperature *float32) error {
+	formats := []TranscriptionFormat{
+		TranscriptionJSON, TranscriptionVerboseJSON, TranscriptionSRT, TranscriptionVTT, TranscriptionText,
+	}
+
+	switch {
+	case model == "":
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model must not be empty"))
+	case format != "" && !slices.Contains(formats, format):
+		return errors.Join(ErrRequiredParam, fmt.Errorf("response format %q is not supported", format))
+	case temperature != nil && (*temperature < 0 || *temperature > 1):
+		return errors.Join(ErrRequiredParam, fmt.Errorf("temperature must be in range [0, 1]"))
+	}
+
+	if _, known := LookupModel(model); known && !model.Has(CapabilityTranscription) {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model %q is not allowed for audio requests", model))
+	}
+
+	return nil
+}
+
+// validate checks the transcription request parameters.
+func (r *TranscriptionRequest) validate() error {
+	if err := validateAudio(r.Model, r.ResponseFormat, r.Temperature); err != nil {
+		return err
+	}
+
+	if r.Model != ModelWhisper1 && r.Model.Has(CapabilityTranscription) &&
+		r.ResponseFormat != "" && r.ResponseFormat != TranscriptionJSON && r.ResponseFormat != TranscriptionText {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model %q supports only json and text formats", r.Model))
+	}
+
+	for _, granularity := range r.TimestampGranularities {
+		if granularity != TimestampWord && granularity != TimestampSegment {
+			return errors.Join(ErrRequiredParam, fmt.Errorf("timestamp granularity %q is not supported", granularity))
+		}
+	}
+
+	if len(r.TimestampGranularities) > 0 && r.ResponseFormat != TranscriptionVerboseJSON {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("timestamp granularities require verbose_json format"))
+	}
+
+	return nil
+}
+
+func (r *TranscriptionRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := r.File.upload()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := audioFields(r.Model, r.Prompt, r.ResponseFormat, r.Temperature)
+	if r.Language != "" {
+		fields = append(fields, formField{name: "language", value: r.Language})
+	}
+
+	for _, granularity := range r.TimestampGranularities {
+		fields = append(fields, formField{name: "timestamp_granularities[]", value: string(granularity)})
+	}
+
+	return newMultipartRequest(ctx, auth, r.Model, fields, []formFile{file})
+}
+
+// replayable returns true if the audio file can be read again.
+func (r *TranscriptionRequest) replayable() bool {
+	return isSeeker(r.File.Reader)
+}
+
+func (r *TranslationRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	if err := validateAudio(r.Model, r.ResponseFormat, r.Temperature); err != nil {
+		return nil, err
+	}
+
+	if r.Model.Has(CapabilityTranscription) && r.Model != ModelWhisper1 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model %q does not support translations", r.Model))
+	}
+
+	file, err := r.File.upload()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := audioFields(r.Model, r.Prompt, r.ResponseFormat, r.Temperature)
+	return newMultipartRequest(ctx, auth, r.Model, fields, []formFile{file})
+}
+
+// replayable returns true if the audio file can be read again.
+func (r *TranslationRequest) replayable() bool {
+	return isSeeker(r.File.Reader)
+}
+
+// TranscriptionSegment is a segment of the verbose transcription.
+type TranscriptionSegment struct {
+	ID               int
+	Seek             int
+	Start            time.Duration
+	End              time.Duration
+	Text             string
+	Tokens           []int
+	Temperature      float64
+	AvgLogprob       float64
+	CompressionRatio float64
+	NoSpeechProb     float64
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, timings are converted from seconds.
+func (s *TranscriptionSegment) UnmarshalJSON(b []byte) error {
+	aux := &struct {
+		ID               int     `json:"id"`
+		Seek             int     `json:"seek"`
+		Start            float64 `json:"start"`
+		End              float64 `json:"end"`
+		Text             string  `json:"text"`
+		Tokens           []int   `json:"tokens"`
+		Temperature      float64 `json:"temperature"`
+		AvgLogprob       float64 `json:"avg_logprob"`
+		CompressionRatio float64 `json:"compression_ratio"`
+		NoSpeechProb     float64 `json:"no_speech_prob"`
+	}{}
+
+	if err := json.Unmarshal(b, aux); err != nil {
+		return errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	*s = TranscriptionSegment{
+		ID:               aux.ID,
+		Seek:             aux.Seek,
+		Start:            secondsDuration(aux.Start),
+		End:              secondsDuration(aux.End),
+		Text:             aux.Text,
+		Tokens:           aux.Tokens,
+		Temperature:      aux.Temperature,
+		AvgLogprob:       aux.AvgLogprob,
+		CompressionRatio: aux.CompressionRatio,
+		NoSpeechProb:     aux.NoSpeechProb,
+	}
+
+	return nil
+}
+
+// TranscriptionWord is a word of the verbose transcription with its timings.
+type TranscriptionWord struct {
+	Word  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, timings are converted from seconds.
+func (w *TranscriptionWord) UnmarshalJSON(b []byte) error {
+	aux := &struct {
+		Word  string  `json:"word"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}{}
+
+	if err := json.Unmarshal(b, aux); err != nil {
+		return errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	*w = TranscriptionWord{Word: aux.Word, Start: secondsDuration(aux.Start), End: secondsDuration(aux.End)}
+	return nil
+}
+
+// secondsDuration converts seconds to the duration.
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// transcriptionUsage is a usage of the transcription API, it is measured in tokens or seconds.
+type transcriptionUsage struct {
+	Type              string  `json:"type"`
+	InputTokens       uint    `json:"input_tokens"`
+	OutputTokens      uint    `json:"output_tokens"`
+	TotalTokens       uint    `json:"total_tokens"`
+	Seconds           float64 `json:"seconds"`
+	InputTokenDetails struct {
+		AudioTokens uint `json:"audio_tokens"`
+	} `json:"input_token_details"`
+}
+
+// TranscriptionResponse is a response of audio transcriptions and translations APIs.
+// Text contains the raw response for srt, vtt and text formats.
+// Language, Duration, Segments and Words are set only for verbose_json format,
+// Usage is set for gpt-4o transcribe models.
+type TranscriptionResponse struct {
+	Task     string
+	Language string
+	Duration time.Duration
+	Text     string
+	Segments []TranscriptionSegment
+	Words    []TranscriptionWord
+	Usage    Usage
+	model    Model
+}
+
+// build reads the response body according to the response format.
+func (t *TranscriptionResponse) build(body io.Reader, format TranscriptionFormat) error {
+	switch format {
+	case TranscriptionSRT, TranscriptionVTT, TranscriptionText:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return errors.Join(ErrResponse, fmt.Errorf("failed to read transcription: %w", err))
+		}
+
+		t.Text = string(data)
+		return nil
+	}
+
+	aux := &struct {
+		Task     string                 `json:"task"`
+		Language string                 `json:"language"`
+		Duration float64                `json:"duration"`
+		Text     string                 `json:"text"`
+		Segments []TranscriptionSegment `json:"segments"`
+		Words    []TranscriptionWord    `json:"words"`
+		Usage    *transcriptionUsage    `json:"usage"`
+	}{}
+
+	if err := json.NewDecoder(body).Decode(aux); err != nil {
+		return errors.Join(ErrResponse, fmt.Errorf("failed to unmarshal transcription: %w", err))
+	}
+
+	t.Task, t.Language, t.Text = aux.Task, aux.Language, aux.Text
+	t.Duration = secondsDuration(aux.Duration)
+	t.Segments, t.Words = aux.Segments, aux.Words
+
+	if u := aux.Usage; u != nil {
+		if u.Type == "duration" && t.Duration == 0 {
+			t.Duration = secondsDuration(u.Seconds)
+		}
+
+		t.Usage = Usage{
+			PromptTokens:        u.InputTokens,
+			CompletionTokens:    u.OutputTokens,
+			TotalTokens:         u.TotalTokens,
+			PromptTokensDetails: PromptTokensDetails{AudioTokens: u.InputTokenDetails.AudioTokens},
+		}
+	}
+
+	return nil
+}
+
+// String returns the transcription text.
+func (t *TranscriptionResponse) String() string {
+	return t.Text
+}
+
+// Cost returns the transcription cost in USD and true if it is known.
+// Tokens usage is used if it is set, otherwise the audio duration.
+func (t *TranscriptionResponse) Cost() (float64, bool) {
+	price, ok := LookupPrice(t.model)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case t.Usage.TotalTokens > 0:
+		return price.Cost(t.Usage), true
+	case t.Duration > 0 && price.AudioMinute > 0:
+		return t.Duration.Minutes() * price.AudioMinute, true
+	default:
+		return 0, false
+	}
+}
+
+// Transcription sends request to the audio transcriptions API.
+func Transcription(
+	ctx context.Context, client *http.Client, r *TranscriptionRequest, p Params,
+) (*TranscriptionResponse, error) {
+	return newParamsClient(client, p).Transcription(ctx, r)
+}
+
+// Translation sends request to the audio translations API.
+func Translation(
+	ctx context.Context, client *http.Client, r *TranslationRequest, p Params,
+) (*TranscriptionResponse, error) {
+	return newParamsClient(client, p).Translation(ctx, r)
+}
@@ -0,0 +1,297 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscription(t *testing.T) {
+	var (
+		audio       = []byte("ID3 audio data")
+		temperature = float32(0.2)
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+
+		var response string
+		switch r.URL.Path {
+		case transcriptionsPath:
+			expected := map[string][]string{
+				"model":                     {"whisper-1"},
+				"language":                  {"en"},
+				"prompt":                    {"Hello"},
+				"response_format":           {"verbose_json"},
+				"temperature":               {"0.2"},
+				"timestamp_granularities[]": {"word", "segment"},
+			}
+			if !reflect.DeepEqual(r.MultipartForm.Value, expected) {
+				t.Errorf("unexpected form %v", r.MultipartForm.Value)
+			}
+
+			f, header, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("failed file: %v", err)
+			}
+
+			if ct := header.Header.Get("Content-Type"); ct != "audio/mpeg" || header.Filename != "hello.mp3" {
+				t.Errorf("unexpected file %q with content type %q", header.Filename, ct)
+			}
+
+			if data, _ := io.ReadAll(f); !bytes.Equal(data, audio) {
+				t.Error("unexpected file data")
+			}
+
+			response = `{"task":"transcribe","language":"english","duration":2.5,"text":"Hello world",` +
+				`"segments":[{"id":0,"seek":0,"start":0.0,"end":2.5,"text":"Hello world","tokens":[50364,2425],` +
+				`"temperature":0.2,"avg_logprob":-0.25,"compression_ratio":0.8,"no_speech_prob":0.01}],` +
+				`"words":[{"word":"Hello","start":0.0,"end":1.2},{"word":"world","start":1.5,"end":2.5}]}`
+		case translationsPath:
+			response = "1\n00:00:00,000 --> 00:00:02,500\nHello world\n"
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	request := &TranscriptionRequest{
+		Model:                  ModelWhisper1,
+		File:                   AudioFile{Name: "/tmp/hello.mp3", Reader: bytes.NewReader(audio)},
+		Language:               "en",
+		Prompt:                 "Hello",
+		ResponseFormat:         TranscriptionVerboseJSON,
+		Temperature:            &temperature,
+		TimestampGranularities: []TimestampGranularity{TimestampWord, TimestampSegment},
+	}
+
+	response, err := client.Transcription(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := response.String(); s != "Hello world" {
+		t.Errorf("unexpected text %q", s)
+	}
+
+	if response.Language != "english" || response.Duration != 2500*time.Millisecond {
+		t.Errorf("unexpected language %q or duration %v", response.Language, response.Duration)
+	}
+
+	segments := []TranscriptionSegment{{
+		End:              2500 * time.Millisecond,
+		Text:             "Hello world",
+		Tokens:           []int{50364, 2425},
+		Temperature:      0.2,
+		AvgLogprob:       -0.25,
+		CompressionRatio: 0.8,
+		NoSpeechProb:     0.01,
+	}}
+	if !reflect.DeepEqual(response.Segments, segments) {
+		t.Errorf("expected %v, got %v", segments, response.Segments)
+	}
+
+	words := []TranscriptionWord{
+		{Word: "Hello", End: 1200 * time.Millisecond},
+		{Word: "world", Start: 1500 * time.Millisecond, End: 2500 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(response.Words, words) {
+		t.Errorf("expected %v, got %v", words, response.Words)
+	}
+
+	if cost, ok := response.Cost(); !ok || !equalCost(cost, 0.00025) {
+		t.Errorf("unexpected cost %v", cost)
+	}
+
+	translation, err := Translation(
+		context.Background(),
+		s.Client(),
+		&TranslationRequest{
+			Model:          ModelWhisper1,
+			File:           AudioFile{Name: "hello.ogg", Reader: strings.NewReader("OggS")},
+			ResponseFormat: TranscriptionSRT,
+		},
+		Params{URL: s.URL + translationsPath},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := "1\n00:00:00,000 --> 00:00:02,500\nHello world\n"; translation.Text != expected {
+		t.Errorf("unexpected translation %q", translation.Text)
+	}
+
+	if _, ok := translation.Cost(); ok {
+		t.Error("expected unknown cost")
+	}
+}
+
+func TestTranscriptionUsage(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		response := `{"text":"Hello","usage":{"type":"tokens","input_tokens":100,"output_tokens":10,` +
+			`"total_tokens":110,"input_token_details":{"audio_tokens":100}}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	request := &TranscriptionRequest{
+		Model: ModelGPT4oMiniTranscribe,
+		File:  AudioFile{Name: "hello.wav", Reader: strings.NewReader("RIFF")},
+	}
+
+	response, err := Transcription(context.Background(), s.Client(), request, Params{URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	usage := Usage{
+		PromptTokens:        100,
+		CompletionTokens:    10,
+		TotalTokens:         110,
+		PromptTokensDetails: PromptTokensDetails{AudioTokens: 100},
+	}
+	if response.Usage != usage {
+		t.Errorf("expected %v, got %v", usage, response.Usage)
+	}
+
+	if cost, ok := response.Cost(); !ok || !equalCost(cost, 0.00035) {
+		t.Errorf("unexpected cost %v", cost)
+	}
+}
+
+func TestTranscriptionFailed(t *testing.T) {
+	var (
+		file        = AudioFile{Name: "a.mp3", Reader: strings.NewReader("ID3")}
+		temperature = float32(1.5)
+	)
+
+	testCases := []struct {
+		name    string
+		request CommonRequest
+		err     string
+	}{
+		{
+			name:    "model",
+			request: &TranscriptionRequest{File: file},
+			err:     "model must not be empty",
+		},
+		{
+			name:    "not audio model",
+			request: &TranscriptionRequest{Model: ModelGPT4oMini, File: file},
+			err:     `model "gpt-4o-mini" is not allowed for audio requests`,
+		},
+		{
+			name:    "format",
+			request: &TranscriptionRequest{Model: ModelWhisper1, File: file, ResponseFormat: "xml"},
+			err:     `response format "xml" is not supported`,
+		},
+		{
+			name:    "gpt-4o format",
+			request: &TranscriptionRequest{Model: ModelGPT4oTranscribe, File: file, ResponseFormat: TranscriptionSRT},
+			err:     `model "gpt-4o-transcribe" supports only json and text formats`,
+		},
+		{
+			name:    "temperature",
+			request: &TranscriptionRequest{Model: ModelWhisper1, File: file, Temperature: &temperature},
+			err:     "temperature must be in range [0, 1]",
+		},
+		{
+			name: "granularity",
+			request: &TranscriptionRequest{
+				Model:                  ModelWhisper1,
+				File:                   file,
+				ResponseFormat:         TranscriptionVerboseJSON,
+				TimestampGranularities: []TimestampGranularity{"char"},
+			},
+			err: `timestamp granularity "char" is not supported`,
+		},
+		{
+			name: "granularity format",
+			request: &TranscriptionRequest{
+				Model:                  ModelWhisper1,
+				File:                   file,
+				TimestampGranularities: []TimestampGranularity{TimestampWord},
+			},
+			err: "timestamp granularities require verbose_json format",
+		},
+		{
+			name:    "extension",
+			request: &TranscriptionRequest{Model: ModelWhisper1, File: AudioFile{Name: "a.txt", Reader: file.Reader}},
+			err:     `audio file "a.txt" format is not supported`,
+		},
+		{
+			name:    "nil reader",
+			request: &TranscriptionRequest{Model: ModelWhisper1, File: AudioFile{Name: "a.mp3"}},
+			err:     "audio file reader must not be nil",
+		},
+		{
+			name: "size",
+			request: &TranscriptionRequest{
+				Model: ModelWhisper1,
+				File:  AudioFile{Name: "a.mp3", Reader: bytes.NewReader(make([]byte, audioUploadMaxSize+1))},
+			},
+			err: "audio file size 26214401 is larger than 26214400 bytes",
+		},
+		{
+			name:    "translation model",
+			request: &TranslationRequest{Model: ModelGPT4oTranscribe, File: file},
+			err:     `model "gpt-4o-transcribe" does not support translations`,
+		},
+		{
+			name:    "translation format",
+			request: &TranslationRequest{Model: ModelWhisper1, File: file, ResponseFormat: "xml"},
+			err:     `response format "xml" is not supported`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := commonRequest(context.Background(), http.DefaultClient, tc.request, Params{URL: ":"})
+			if !errors.Is(err, ErrRequiredParam) || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestTranscriptionResponseBuild(t *testing.T) {
+	response := &TranscriptionResponse{}
+
+	err := response.build(strings.NewReader(`{"segments":[{"start":"0"}]}`), TranscriptionJSON)
+	if !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	if err = response.build(strings.NewReader(`{"words":[{"end":"1"}]}`), ""); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	err = response.build(strings.NewReader(`{"text":"Hi","usage":{"type":"duration","seconds":30}}`), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response.model = ModelWhisper1
+	if cost, ok := response.Cost(); !ok || !equalCost(cost, 0.003) {
+		t.Errorf("unexpected cost %v", cost)
+	}
+}
@@ -0,0 +1,93 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AzureAPIVersion is the default Azure OpenAI API version.
+const AzureAPIVersion = "2024-10-21"
+
+// AzureTokenSource returns a Microsoft Entra ID access token.
+// It is called for every request, so it should cache tokens until their expiration.
+type AzureTokenSource func(ctx context.Context) (string, error)
+
+// Azure is an Azure OpenAI provider.
+// Endpoint is the resource URL, if it is empty, it is built from Resource name.
+// If Deployment is empty, the request model name is used as the deployment name.
+// APIKey is sent as "api-key" header, otherwise TokenSource is used for bearer authentication.
+type Azure struct {
+	Resource    string
+	Endpoint    string
+	Deployment  string
+	APIVersion  string
+	APIKey      string
+	TokenSource AzureTokenSource
+}
+
+// endpoint returns the resource endpoint URL without a trailing slash.
+func (a *Azure) endpoint() (string, error) {
+	if a.Endpoint != "" {
+		return strings.TrimRight(a.Endpoint, "/"), nil
+	}
+
+	if a.Resource == "" {
+		return "", errors.Join(ErrRequiredParam, fmt.Errorf("azure resource or endpoint must not be empty"))
+	}
+
+	return fmt.Sprintf("https://%s.openai.azure.com", url.PathEscape(a.Resource)), nil
+}
+
+// URL returns the deployment URL with the API version query parameter.
+// Models listing is not bound to a deployment.
+func (a *Azure) URL(path string, model Model) (string, error) {
+	endpoint, err := a.endpoint()
+	if err != nil {
+		return "", err
+	}
+
+	version := a.APIVersion
+	if version == "" {
+		version = AzureAPIVersion
+	}
+
+	query := url.Values{"api-version": {version}}
+	if path == modelsPath {
+		return fmt.Sprintf("%s/openai%s?%s", endpoint, path, query.Encode()), nil
+	}
+
+	deployment := a.Deployment
+	if deployment == "" {
+		deployment = string(model)
+	}
+
+	if deployment == "" {
+		return "", errors.Join(ErrRequiredParam, fmt.Errorf("azure deployment must not be empty"))
+	}
+
+	return fmt.Sprintf("%s/openai/deployments/%s%s?%s", endpoint, url.PathEscape(deployment), path, query.Encode()), nil
+}
+
+// Authorize sets "api-key" header or Entra ID bearer token.
+func (a *Azure) Authorize(ctx context.Context, header http.Header) error {
+	if a.APIKey != "" {
+		header.Set("api-key", a.APIKey)
+		return nil
+	}
+
+	if a.TokenSource == nil {
+		return fmt.Errorf("azure API key or token source must be set")
+	}
+
+	token, err := a.TokenSource(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get azure token: %w", err)
+	}
+
+	header.Set("Authorization", "Bearer "+token)
+	return nil
+}
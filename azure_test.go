@@ -0,0 +1,195 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		azure    Azure
+		path     string
+		model    Model
+		expected string
+		err      bool
+	}{
+		{
+			name:     "resource",
+			azure:    Azure{Resource: "test"},
+			path:     completionsPath,
+			model:    ModelGPT4oMini,
+			expected: "https://test.openai.azure.com/openai/deployments/gpt-4o-mini/chat/completions?api-version=2024-10-21",
+		},
+		{
+			name:     "endpoint",
+			azure:    Azure{Endpoint: "https://localhost:8080/", Deployment: "prod embeddings", APIVersion: "2025-01-01"},
+			path:     embeddingsPath,
+			model:    ModelTextEmbedding3Small,
+			expected: "https://localhost:8080/openai/deployments/prod%20embeddings/embeddings?api-version=2025-01-01",
+		},
+		{
+			name:     "models",
+			azure:    Azure{Resource: "test", Deployment: "unused"},
+			path:     modelsPath,
+			expected: "https://test.openai.azure.com/openai/models?api-version=2024-10-21",
+		},
+		{
+			name:  "no resource",
+			azure: Azure{Deployment: "test"},
+			path:  completionsPath,
+			err:   true,
+		},
+		{
+			name:  "no deployment",
+			azure: Azure{Resource: "test"},
+			path:  imagesPath,
+			err:   true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := tc.azure.URL(tc.path, tc.model)
+			if tc.err {
+				if !errors.Is(err, ErrRequiredParam) {
+					t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if u != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, u)
+			}
+		})
+	}
+}
+
+func TestAzureAuthorize(t *testing.T) {
+	testCases := []struct {
+		name   string
+		azure  Azure
+		header string
+		value  string
+		err    bool
+	}{
+		{
+			name:   "api key",
+			azure:  Azure{APIKey: "key", TokenSource: func(context.Context) (string, error) { return "token", nil }},
+			header: "api-key",
+			value:  "key",
+		},
+		{
+			name:   "token",
+			azure:  Azure{TokenSource: func(context.Context) (string, error) { return "token", nil }},
+			header: "Authorization",
+			value:  "Bearer token",
+		},
+		{
+			name:  "token error",
+			azure: Azure{TokenSource: func(context.Context) (string, error) { return "", errors.New("expired") }},
+			err:   true,
+		},
+		{
+			name: "no credentials",
+			err:  true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			err := tc.azure.Authorize(context.Background(), header)
+
+			if tc.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if value := header.Get(tc.header); value != tc.value {
+				t.Errorf("expected %q, got %q", tc.value, value)
+			}
+		})
+	}
+}
+
+func TestAzureClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("unexpected authorization header %q", auth)
+		}
+
+		if key := r.Header.Get("api-key"); key != "test-key" {
+			t.Errorf("unexpected api-key header %q", key)
+		}
+
+		if version := r.URL.Query().Get("api-version"); version != AzureAPIVersion {
+			t.Errorf("unexpected api version %q", version)
+		}
+
+		var response string
+		switch r.URL.Path {
+		case "/openai/deployments/gpt-4o-mini/chat/completions":
+			response = `{"id":"test","object":"chat.completion","created":1677652288,` +
+				`"choices":[{"index":0,"message":{"content":"Hello","role":"assistant"},"finish_reason":"stop"}],` +
+				`"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`
+		case "/openai/deployments/dall-e-3/images/generations":
+			response = `{"created":1677652288,"data":[{"url":"https://localhost/image.png"}]}`
+		case "/openai/deployments/text-embedding-3-small/embeddings":
+			response = `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5]}],` +
+				`"model":"text-embedding-3-small","usage":{"prompt_tokens":1,"total_tokens":1}}`
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	azure := &Azure{Endpoint: s.URL, APIKey: "test-key"}
+	client := NewClient(WithProvider(azure), WithBearer("unused"), WithHTTPClient(s.Client()))
+	ctx := context.Background()
+
+	messages := []Message{{Role: RoleUser, Content: "Hello"}}
+	completion, err := client.Completion(ctx, &CompletionRequest{Model: ModelGPT4oMini, Messages: messages})
+	if err != nil {
+		t.Fatalf("unexpected completion error: %v", err)
+	}
+
+	if s := completion.String(); s != "Hello" {
+		t.Errorf("unexpected completion %q", s)
+	}
+
+	if _, err = client.Image(ctx, &ImageRequest{Model: ModelDalle3, Prompt: "cat"}); err != nil {
+		t.Errorf("unexpected image error: %v", err)
+	}
+
+	embedding := &EmbeddingRequest{Model: ModelTextEmbedding3Small, Input: []string{"a"}}
+	if _, err = Embeddings(ctx, s.Client(), embedding, Params{Provider: azure}); err != nil {
+		t.Errorf("unexpected embeddings error: %v", err)
+	}
+
+	failed := NewClient(WithProvider(&Azure{Endpoint: s.URL}), WithHTTPClient(s.Client()))
+	_, err = failed.Completion(ctx, &CompletionRequest{Model: ModelGPT4oMini, Messages: messages})
+	if !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected %v, got %v", ErrAuthentication, err)
+	}
+}
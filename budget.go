@@ -0,0 +1,475 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// BudgetScope is a type of budget limit scope.
+type BudgetScope string
+
+// Budget scopes, key limits are applied to every client key and user ones to every request user.
+const (
+	BudgetGlobal BudgetScope = "global"
+	BudgetKey    BudgetScope = "key"
+	BudgetUser   BudgetScope = "user"
+)
+
+// BudgetLimit is a limit of tokens and cost in USD over a rolling window.
+// Zero Tokens or Cost means that it is not limited.
+type BudgetLimit struct {
+	Tokens uint
+	Cost   float64
+	Window time.Duration
+}
+
+// BudgetOption is a function to configure the budget.
+type BudgetOption func(b *Budget)
+
+// WithBudgetLimit sets the limit of the scope.
+func WithBudgetLimit(scope BudgetScope, limit BudgetLimit) BudgetOption {
+	return func(b *Budget) {
+		b.limits[scope] = limit
+	}
+}
+
+// BudgetError is an error of the exceeded budget limit.
+// Name is a key or user name of the scope, Tokens and Cost are spent values including the rejected request.
+// It matches ErrBudgetExceeded with errors.Is.
+type BudgetError struct {
+	Scope  BudgetScope
+	Name   string
+	Limit  BudgetLimit
+	Tokens uint
+	Cost   float64
+}
+
+// Error returns the error message.
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v: %s %q limit %d tokens, $%g per %v, gotten %d tokens, $%g",
+		ErrBudgetExceeded, e.Scope, e.Name, e.Limit.Tokens, e.Limit.Cost, e.Limit.Window, e.Tokens, e.Cost,
+	)
+}
+
+// Is reports whether the error matches the target error.
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// spend is a tokens and cost of one request.
+type spend struct {
+	at     time.Time
+	tokens uint
+	cost   float64
+}
+
+// budgetName is a key of the budget ledger.
+type budgetName struct {
+	scope BudgetScope
+	name  string
+}
+
+// Budget tracks tokens and cost of requests over rolling windows.
+// Requests are rejected before sending if their estimation exceeds the remaining budget,
+// then the estimation is replaced by the response usage.
+// Cost is calculated by the pricing registry, models without price are counted as free.
+// It is safe for concurrent use.
+type Budget struct {
+	mu      sync.Mutex
+	limits  map[BudgetScope]BudgetLimit
+	ledgers map[budgetName][]*spend
+	now     func() time.Time
+}
+
+// NewBudget creates a new budget with the limits.
+func NewBudget(options ...BudgetOption) (*Budget, error) {
+	b := &Budget{
+		limits:  make(map[BudgetScope]BudgetLimit),
+		ledgers: make(map[budgetName][]*spend),
+		now:     time.Now,
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	if len(b.limits) == 0 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("budget limits must not be empty"))
+	}
+
+	for scope, limit := range b.limits {
+		switch scope {
+		case BudgetGlobal, BudgetKey, BudgetUser:
+		default:
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("unknown budget scope %q", scope))
+		}
+
+		if limit.Window <= 0 || limit.Cost < 0 {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("invalid %s budget limit %+v", scope, limit))
+		}
+	}
+
+	return b, nil
+}
+
+// names returns the budget ledgers names of the request.
+// Requests without a user are not limited by the user scope.
+func (b *Budget) names(key, user string) []budgetName {
+	names := []budgetName{{scope: BudgetGlobal}, {scope: BudgetKey, name: key}}
+
+	if user != "" {
+		names = append(names, budgetName{scope: BudgetUser, name: user})
+	}
+
+	return names
+}
+
+// spent returns the tokens and cost of the ledger in the limit window, expired spends are removed.
+func (b *Budget) spent(name budgetName, limit BudgetLimit, now time.Time) (uint, float64) {
+	var (
+		tokens uint
+		cost   float64
+		spends = b.ledgers[name]
+		start  = now.Add(-limit.Window)
+		i      int
+	)
+
+	for i < len(spends) && !spends[i].at.After(start) {
+		i++
+	}
+
+	spends = spends[i:]
+	for _, s := range spends {
+		tokens += s.tokens
+		cost += s.cost
+	}
+
+	if len(spends) == 0 {
+		delete(b.ledgers, name)
+	} else {
+		b.ledgers[name] = spends
+	}
+
+	return tokens, cost
+}
+
+// reserve checks the estimated tokens and cost and adds them to the budget.
+func (b *Budget) reserve(key, user string, tokens uint, cost float64) (*spend, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	names := b.names(key, user)
+
+	for _, name := range names {
+		limit, ok := b.limits[name.scope]
+		if !ok {
+			continue
+		}
+
+		spentTokens, spentCost := b.spent(name, limit, now)
+		spentTokens, spentCost = spentTokens+tokens, spentCost+cost
+
+		if (limit.Tokens > 0 && spentTokens > limit.Tokens) || (limit.Cost > 0 && spentCost > limit.Cost) {
+			return nil, &BudgetError{
+				Scope:  name.scope,
+				Name:   name.name,
+				Limit:  limit,
+				Tokens: spentTokens,
+				Cost:   spentCost,
+			}
+		}
+	}
+
+	s := &spend{at: now, tokens: tokens, cost: cost}
+	for _, name := range names {
+		if _, ok := b.limits[name.scope]; ok {
+			b.ledgers[name] = append(b.ledgers[name], s)
+		}
+	}
+
+	return s, nil
+}
+
+// commit replaces the reserved spend by the actual one.
+func (b *Budget) commit(s *spend, tokens uint, cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s.tokens, s.cost = tokens, cost
+}
+
+// Spent returns the tokens and cost of the scope name in the current window.
+// Name is ignored for the global scope.
+func (b *Budget) Spent(scope BudgetScope, name string) (uint, float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	limit, ok := b.limits[scope]
+	if !ok {
+		return 0, 0
+	}
+
+	if scope == BudgetGlobal {
+		name = ""
+	}
+
+	return b.spent(budgetName{scope: scope, name: name}, limit, b.now())
+}
+
+// Client returns the client wrapper which checks the budget of the key.
+// Key identifies the client, for example, a name of the API key.
+func (b *Budget) Client(client *Client, key string) *BudgetClient {
+	return &BudgetClient{client: client, budget: b, key: key}
+}
+
+// BudgetClient is a client wrapper which checks and tracks the budget of requests.
+// It wraps chat completions and their streams, embeddings, images and audio requests.
+type BudgetClient struct {
+	client *Client
+	budget *Budget
+	key    string
+}
+
+// usageCost returns the cost of the usage or zero if the model price is unknown.
+func usageCost(model Model, usage Usage) float64 {
+	price, ok := LookupPrice(model)
+	if !ok {
+		return 0
+	}
+
+	return price.Cost(usage)
+}
+
+// track reserves the estimation of the user request, sends it and replaces the estimation by the spent tokens and cost.
+// If send returns false, the spend is unknown and the estimation is kept.
+// Failed requests do not spend the budget.
+func (c *BudgetClient) track(
+	user string, estimation Usage, cost float64, send func() (uint, float64, bool, error),
+) error {
+	s, err := c.budget.reserve(c.key, user, estimation.TotalTokens, cost)
+	if err != nil {
+		return err
+	}
+
+	tokens, spent, ok, err := send()
+	switch {
+	case err != nil:
+		c.budget.commit(s, 0, 0)
+		return err
+	case ok:
+		c.budget.commit(s, tokens, spent)
+	}
+
+	return nil
+}
+
+// Completion checks the budget, sends the chat completion request and records its usage.
+// The request estimation uses its prompt tokens and MaxTokens for every choice,
+// the model max tokens are used if MaxTokens is not set, so the estimation is pessimistic without it.
+// Responses without usage, for example, streamed ones without usage option, are recorded by the estimation.
+func (c *BudgetClient) Completion(ctx context.Context, r *CompletionRequest) (*CompletionResponse, error) {
+	var (
+		request    = c.client.completionRequest(r)
+		estimation = completionEstimation(request)
+		response   *CompletionResponse
+	)
+
+	err := c.track(request.User, estimation, usageCost(request.Model, estimation), func() (uint, float64, bool, error) {
+		var err error
+		if response, err = c.client.Completion(ctx, request); err != nil {
+			return 0, 0, false, err
+		}
+
+		usage := response.Usage
+		return usage.TotalTokens, usageCost(request.Model, usage), usage.TotalTokens > 0, nil
+	})
+
+	return response, err
+}
+
+// CompletionStream checks the budget and sends the streaming chat completion request.
+// The request is estimated like by Completion, usage is always requested by StreamOptions,
+// and the estimation is replaced by the usage of the final chunk when the stream is closed.
+// If the stream is closed before the usage chunk, the estimation is kept.
+func (c *BudgetClient) CompletionStream(ctx context.Context, r *CompletionRequest) (*Stream, error) {
+	request := *c.client.completionRequest(r)
+	request.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	estimation := completionEstimation(&request)
+
+	s, err := c.budget.reserve(c.key, request.User, estimation.TotalTokens, usageCost(request.Model, estimation))
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := c.client.CompletionStream(ctx, &request)
+	if err != nil {
+		c.budget.commit(s, 0, 0)
+		return nil, err
+	}
+
+	stream.onClose = func(usage Usage) {
+		if usage.TotalTokens > 0 {
+			c.budget.commit(s, usage.TotalTokens, usageCost(request.Model, usage))
+		}
+	}
+
+	return stream, nil
+}
+
+// completionEstimation returns the pessimistic usage of the chat completion request.
+func completionEstimation(request *CompletionRequest) Usage {
+	choices := uint(1)
+	if request.N != nil && *request.N > 1 {
+		choices = *request.N
+	}
+
+	maxTokens := request.MaxTokens
+	if limit, ok := request.Model.maxTokens(); ok && maxTokens == 0 {
+		maxTokens = limit
+	}
+
+	estimation := Usage{PromptTokens: uint(CountTokens(request.Messages, request.Model))}
+	estimation.CompletionTokens = maxTokens * choices
+	estimation.TotalTokens = estimation.PromptTokens + estimation.CompletionTokens
+
+	return estimation
+}
+
+// Embeddings checks the budget, sends the embeddings request and records its usage.
+func (c *BudgetClient) Embeddings(ctx context.Context, e *EmbeddingRequest) (*EmbeddingResponse, error) {
+	var (
+		count      = tokenCounter(e.Model)
+		estimation Usage
+		response   *EmbeddingResponse
+	)
+
+	for _, input := range e.Input {
+		estimation.PromptTokens += uint(count(input))
+	}
+
+	estimation.TotalTokens = estimation.PromptTokens
+
+	err := c.track(e.User, estimation, usageCost(e.Model, estimation), func() (uint, float64, bool, error) {
+		var err error
+		if response, err = c.client.Embeddings(ctx, e); err != nil {
+			return 0, 0, false, err
+		}
+
+		usage := response.Usage
+		return usage.TotalTokens, usageCost(e.Model, usage), usage.TotalTokens > 0, nil
+	})
+
+	return response, err
+}
+
+// imageCost returns the estimated cost of images of the request options or zero if their price is unknown.
+func imageCost(options *ImageRequest) float64 {
+	model, size, quality := options.variant()
+
+	price, ok := LookupPrice(model)
+	if !ok {
+		return 0
+	}
+
+	cost, _ := price.ImageCost(size, quality)
+	return cost * float64(max(options.N, 1))
+}
+
+// trackImage checks the budget by the images price, sends the image request and records the response cost.
+func (c *BudgetClient) trackImage(
+	user string, options *ImageRequest, send func() (*ImageResponse, error),
+) (*ImageResponse, error) {
+	var response *ImageResponse
+
+	err := c.track(user, Usage{}, imageCost(options), func() (uint, float64, bool, error) {
+		var err error
+		if response, err = send(); err != nil {
+			return 0, 0, false, err
+		}
+
+		cost, ok := response.Cost()
+		return 0, cost, ok, nil
+	})
+
+	return response, err
+}
+
+// Image checks the budget, sends the image generation request and records its cost.
+func (c *BudgetClient) Image(ctx context.Context, i *ImageRequest) (*ImageResponse, error) {
+	return c.trackImage(i.User, i, func() (*ImageResponse, error) {
+		return c.client.Image(ctx, i)
+	})
+}
+
+// ImageEdit checks the budget, sends the image edits request and records its cost.
+func (c *BudgetClient) ImageEdit(ctx context.Context, r *ImageEditRequest) (*ImageResponse, error) {
+	return c.trackImage(r.User, r.options(), func() (*ImageResponse, error) {
+		return c.client.ImageEdit(ctx, r)
+	})
+}
+
+// ImageVariation checks the budget, sends the image variations request and records its cost.
+func (c *BudgetClient) ImageVariation(ctx context.Context, r *ImageVariationRequest) (*ImageResponse, error) {
+	return c.trackImage(r.User, r.options(), func() (*ImageResponse, error) {
+		return c.client.ImageVariation(ctx, r)
+	})
+}
+
+// trackAudio checks that the budget is not exceeded, sends the audio request and records its usage and cost.
+// The audio duration is unknown before sending, so there is no pre-flight estimation.
+func (c *BudgetClient) trackAudio(send func() (*TranscriptionResponse, error)) (*TranscriptionResponse, error) {
+	var response *TranscriptionResponse
+
+	err := c.track("", Usage{}, 0, func() (uint, float64, bool, error) {
+		var err error
+		if response, err = send(); err != nil {
+			return 0, 0, false, err
+		}
+
+		cost, _ := response.Cost()
+		return response.Usage.TotalTokens, cost, true, nil
+	})
+
+	return response, err
+}
+
+// Transcription checks the budget, sends the audio transcriptions request and records its cost.
+func (c *BudgetClient) Transcription(ctx context.Context, r *TranscriptionRequest) (*TranscriptionResponse, error) {
+	return c.trackAudio(func() (*TranscriptionResponse, error) {
+		return c.client.Transcription(ctx, r)
+	})
+}
+
+// Translation checks the budget, sends the audio translations request and records its cost.
+func (c *BudgetClient) Translation(ctx context.Context, r *TranslationRequest) (*TranscriptionResponse, error) {
+	return c.trackAudio(func() (*TranscriptionResponse, error) {
+		return c.client.Translation(ctx, r)
+	})
+}
+
+// Speech checks the budget, sends the text-to-speech request and records its estimation.
+// Input characters are counted as prompt tokens, it is not less than the number of text tokens,
+// the audio output tokens are not known, so they are not counted.
+// A caller must close the stream if no error.
+func (c *BudgetClient) Speech(ctx context.Context, r *SpeechRequest) (io.ReadCloser, error) {
+	var (
+		tokens     = uint(utf8.RuneCountInString(r.Input))
+		estimation = Usage{PromptTokens: tokens, TotalTokens: tokens}
+		stream     io.ReadCloser
+	)
+
+	err := c.track("", estimation, usageCost(r.Model, estimation), func() (uint, float64, bool, error) {
+		var err error
+		stream, err = c.client.Speech(ctx, r)
+		return 0, 0, false, err
+	})
+
+	return stream, err
+}
@@ -0,0 +1,335 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewBudget(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options []BudgetOption
+		err     bool
+	}{
+		{
+			name:    "valid",
+			options: []BudgetOption{WithBudgetLimit(BudgetGlobal, BudgetLimit{Tokens: 10, Window: time.Hour})},
+		},
+		{
+			name: "empty",
+			err:  true,
+		},
+		{
+			name:    "window",
+			options: []BudgetOption{WithBudgetLimit(BudgetUser, BudgetLimit{Tokens: 10})},
+			err:     true,
+		},
+		{
+			name:    "cost",
+			options: []BudgetOption{WithBudgetLimit(BudgetKey, BudgetLimit{Cost: -1, Window: time.Hour})},
+			err:     true,
+		},
+		{
+			name:    "scope",
+			options: []BudgetOption{WithBudgetLimit("unknown", BudgetLimit{Tokens: 10, Window: time.Hour})},
+			err:     true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewBudget(tc.options...)
+			if tc.err {
+				if !errors.Is(err, ErrRequiredParam) {
+					t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBudgetWindow(t *testing.T) {
+	budget, err := NewBudget(
+		WithBudgetLimit(BudgetGlobal, BudgetLimit{Cost: 1, Window: time.Hour}),
+		WithBudgetLimit(BudgetUser, BudgetLimit{Tokens: 100, Window: time.Minute}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget.now = func() time.Time { return now }
+
+	s, err := budget.reserve("key", "alice", 80, 0.5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = budget.reserve("key", "alice", 30, 0.1)
+
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+
+	if budgetErr.Scope != BudgetUser || budgetErr.Name != "alice" || budgetErr.Tokens != 110 {
+		t.Errorf("unexpected error: %#v", budgetErr)
+	}
+
+	// other user has own limit, but the global cost is shared
+	if _, err = budget.reserve("key", "bob", 90, 0.6); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+
+	budget.commit(s, 20, 0.1)
+	if _, err = budget.reserve("key", "alice", 30, 0.1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if tokens, cost := budget.Spent(BudgetUser, "alice"); tokens != 50 || !equalCost(cost, 0.2) {
+		t.Errorf("unexpected spent %d, %v", tokens, cost)
+	}
+
+	now = now.Add(time.Minute)
+	if tokens, cost := budget.Spent(BudgetUser, "alice"); tokens != 0 || cost != 0 {
+		t.Errorf("unexpected spent %d, %v", tokens, cost)
+	}
+
+	if tokens, cost := budget.Spent(BudgetGlobal, "ignored"); tokens != 50 || !equalCost(cost, 0.2) {
+		t.Errorf("unexpected spent %d, %v", tokens, cost)
+	}
+
+	if tokens, _ := budget.Spent(BudgetKey, "key"); tokens != 0 {
+		t.Errorf("unexpected spent %d of not limited scope", tokens)
+	}
+
+	// requests without a user do not share one user limit
+	for range 2 {
+		if _, err = budget.reserve("key", "", 90, 0); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if tokens, _ := budget.Spent(BudgetUser, ""); tokens != 0 {
+		t.Errorf("unexpected spent %d of anonymous user", tokens)
+	}
+}
+
+func TestBudgetClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, embeddingsPath) {
+			http.Error(w, `{"error":{"message":"failed","type":"server_error"}}`, http.StatusBadRequest)
+			return
+		}
+
+		response := `{"id":"test","object":"chat.completion","created":1677652288,` +
+			`"choices":[{"index":0,"message":{"content":"Hello","role":"assistant"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	budget, err := NewBudget(WithBudgetLimit(BudgetKey, BudgetLimit{Tokens: 100, Window: time.Hour}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := budget.Client(NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client())), "batch")
+	ctx := context.Background()
+	request := &CompletionRequest{
+		Model:     ModelGPT4oMini,
+		Messages:  []Message{{Role: RoleUser, Content: "Hi"}},
+		MaxTokens: 10,
+	}
+
+	for range 3 {
+		if _, err = client.Completion(ctx, request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if tokens, _ := budget.Spent(BudgetKey, "batch"); tokens != 90 {
+		t.Errorf("unexpected spent %d", tokens)
+	}
+
+	// pre-flight estimation exceeds the remaining budget
+	request.MaxTokens = 50
+	if _, err = client.Completion(ctx, request); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+
+	// the model max tokens are estimated if MaxTokens is not set
+	other := budget.Client(NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client())), "other")
+	request.MaxTokens = 0
+	if _, err = other.Completion(ctx, request); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+
+	// failed request does not spend the budget
+	embedding := &EmbeddingRequest{Model: ModelTextEmbedding3Small, Input: []string{"a", "b"}}
+	if _, err = other.Embeddings(ctx, embedding); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	if tokens, cost := budget.Spent(BudgetKey, "other"); tokens != 0 || cost != 0 {
+		t.Errorf("unexpected spent %d, %v", tokens, cost)
+	}
+}
+
+func TestBudgetClientStream(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if s := string(data); !strings.Contains(s, `"stream_options":{"include_usage":true}`) {
+			t.Errorf("usage is not requested: %s", s)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if _, err = fmt.Fprint(w, testStreamBody); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	budget, err := NewBudget(WithBudgetLimit(BudgetKey, BudgetLimit{Tokens: 100, Window: time.Hour}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := budget.Client(NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client())), "stream")
+	ctx := context.Background()
+	// estimation is 58 tokens: reply 3, user 3+1+1 and 50 max tokens
+	request := &CompletionRequest{
+		Model:     ModelGPT4oMini,
+		Messages:  []Message{{Role: RoleUser, Content: "Hi"}},
+		MaxTokens: 50,
+	}
+
+	stream, err := client.CompletionStream(ctx, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.StreamOptions != nil {
+		t.Error("request must not be changed")
+	}
+
+	if tokens, _ := budget.Spent(BudgetKey, "stream"); tokens != 58 {
+		t.Errorf("unexpected reserved %d", tokens)
+	}
+
+	// the final chunk usage is committed on close
+	if _, err = stream.Response(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tokens, _ := budget.Spent(BudgetKey, "stream"); tokens != 10 {
+		t.Errorf("unexpected spent %d", tokens)
+	}
+
+	// the estimation is kept if the stream is closed before the usage chunk
+	if stream, err = client.CompletionStream(ctx, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = stream.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if tokens, _ := budget.Spent(BudgetKey, "stream"); tokens != 68 {
+		t.Errorf("unexpected spent %d", tokens)
+	}
+
+	if _, err = client.CompletionStream(ctx, request); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+}
+
+func TestBudgetClientMedia(t *testing.T) {
+	var requests int
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response string
+		requests++
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, imagesPath):
+			response = `{"created":1677652288,"data":[{"url":"https://localhost/1.png"}]}`
+		case strings.HasSuffix(r.URL.Path, transcriptionsPath):
+			response = `{"task":"transcribe","language":"english","duration":60,"text":"Hello"}`
+		case strings.HasSuffix(r.URL.Path, speechPath):
+			response = "audio"
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	budget, err := NewBudget(WithBudgetLimit(BudgetGlobal, BudgetLimit{Cost: 0.1, Window: time.Hour}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := budget.Client(NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client())), "media")
+	ctx := context.Background()
+
+	if _, err = client.Image(ctx, &ImageRequest{Model: ModelDalle3, Prompt: "cat", Quality: ImageQualityHD}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transcription := &TranscriptionRequest{
+		Model:          ModelWhisper1,
+		File:           AudioFile{Name: "a.mp3", Reader: strings.NewReader("ID3")},
+		ResponseFormat: TranscriptionVerboseJSON,
+	}
+	if _, err = client.Transcription(ctx, transcription); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stream, err := client.Speech(ctx, &SpeechRequest{Model: ModelTTS1, Voice: VoiceAlloy, Input: "Hello"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = stream.Close(); err != nil {
+		t.Error(err)
+	}
+
+	// 0.08 for the image, 0.006 for one minute of audio and 5 characters of speech
+	if tokens, cost := budget.Spent(BudgetGlobal, ""); tokens != 5 || !equalCost(cost, 0.086075) {
+		t.Errorf("unexpected spent %d, %v", tokens, cost)
+	}
+
+	// the most expensive auto image variant exceeds the remaining budget
+	_, err = client.Image(ctx, &ImageRequest{Model: ModelGPTImage1, Prompt: "cat"})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+
+	if requests != 3 {
+		t.Errorf("unexpected requests number %d", requests)
+	}
+}
@@ -0,0 +1,380 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIBaseURL is the default base URL for the OpenAI API.
+const OpenAIBaseURL = "https://api.openai.com/v1"
+
+// API endpoints paths relative to the base URL.
+const (
+	completionsPath = "/chat/completions"
+	imagesPath      = "/images/generations"
+	imageEditsPath  = "/images/edits"
+	variationsPath  = "/images/variations"
+	embeddingsPath  = "/embeddings"
+	modelsPath      = "/models"
+
+	transcriptionsPath = "/audio/transcriptions"
+	translationsPath   = "/audio/translations"
+	speechPath         = "/audio/speech"
+)
+
+// Client is a reusable API client with default parameters.
+// It is safe for concurrent use if it is not modified after creation.
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	defaultModel Model
+	params       Params
+}
+
+// Option is a function to configure the client.
+type Option func(*Client)
+
+// WithBaseURL sets the API base URL, for example "https://api.deepseek.com/v1".
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithBearer sets the API bearer token.
+func WithBearer(bearer string) Option {
+	return func(c *Client) {
+		c.params.Bearer = bearer
+	}
+}
+
+// WithOrganization sets the OpenAI organization ID.
+func WithOrganization(organization string) Option {
+	return func(c *Client) {
+		c.params.Organization = organization
+	}
+}
+
+// WithProject sets the OpenAI project ID.
+func WithProject(project string) Option {
+	return func(c *Client) {
+		c.params.Project = project
+	}
+}
+
+// WithDefaultModel sets the model for completion requests without a model.
+func WithDefaultModel(model Model) Option {
+	return func(c *Client) {
+		c.defaultModel = model
+	}
+}
+
+// WithHeader adds the header to every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.params.Headers == nil {
+			c.params.Headers = make(http.Header)
+		}
+
+		c.params.Headers.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.params.UserAgent = userAgent
+	}
+}
+
+// WithStopMarker sets the marker which is added to the response string if it is truncated by tokens limit.
+func WithStopMarker(marker string) Option {
+	return func(c *Client) {
+		c.params.StopMarker = marker
+	}
+}
+
+// WithRetry sets the retry policy for failed requests.
+func WithRetry(policy *RetryPolicy) Option {
+	return func(c *Client) {
+		c.params.Retry = policy
+	}
+}
+
+// WithProvider sets the API provider, it defines requests URL and authentication.
+func WithProvider(provider Provider) Option {
+	return func(c *Client) {
+		c.params.Provider = provider
+	}
+}
+
+// WithHTTPClient sets the HTTP client, http.DefaultClient is used by default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// NewClient creates a new API client with OpenAI base URL and the options.
+func NewClient(options ...Option) *Client {
+	c := &Client{baseURL: OpenAIBaseURL}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+
+	return c
+}
+
+// newParamsClient creates a client which sends requests to the params URL as is.
+func newParamsClient(client *http.Client, p Params) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Client{httpClient: client, params: p}
+}
+
+// requestParams returns a copy of the client params with URL for the endpoint path.
+func (c *Client) requestParams(path string) Params {
+	p := c.params
+	p.path = path
+
+	if c.baseURL != "" {
+		p.URL = c.baseURL + path
+	}
+
+	return p
+}
+
+// completionRequest returns the request with the default model if it is not set.
+func (c *Client) completionRequest(r *CompletionRequest) *CompletionRequest {
+	if r.Model != "" || c.defaultModel == "" {
+		return r
+	}
+
+	request := *r
+	request.Model = c.defaultModel
+
+	return &request
+}
+
+// Completion sends a request to the chat completion API and returns a response.
+// If the request has enabled Stream, the response is assembled from the received chunks.
+func (c *Client) Completion(ctx context.Context, r *CompletionRequest) (*CompletionResponse, error) {
+	if r.isStream() {
+		stream, err := c.CompletionStream(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return stream.Response()
+	}
+
+	var (
+		request = c.completionRequest(r)
+		p       = c.requestParams(completionsPath)
+	)
+
+	body, err := commonRequest(ctx, c.httpClient, request, p)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	response, err := decodeCompletion(body, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	response.model = request.Model
+	return response, nil
+}
+
+// CompletionStream sends a streaming request to the chat completion API and returns a stream of chunks.
+// A caller must close the stream if no error.
+func (c *Client) CompletionStream(ctx context.Context, r *CompletionRequest) (*Stream, error) {
+	var (
+		stream  = true
+		request = *c.completionRequest(r)
+		p       = c.requestParams(completionsPath)
+	)
+
+	if _, ok := p.Provider.(CompletionAdapter); ok {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("streaming is not supported by the provider"))
+	}
+
+	request.Stream = &stream
+
+	body, err := commonRequest(ctx, c.httpClient, &request, p)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newStream(ctx, body, p.StopMarker)
+	s.response.model = request.Model
+
+	return s, nil
+}
+
+// Image sends request to the image generation API.
+func (c *Client) Image(ctx context.Context, i *ImageRequest) (*ImageResponse, error) {
+	return c.imageRequest(ctx, i, imagesPath, i)
+}
+
+// ImageEdit sends request to the image edits API.
+func (c *Client) ImageEdit(ctx context.Context, r *ImageEditRequest) (*ImageResponse, error) {
+	return c.imageRequest(ctx, r, imageEditsPath, r.options())
+}
+
+// ImageVariation sends request to the image variations API.
+func (c *Client) ImageVariation(ctx context.Context, r *ImageVariationRequest) (*ImageResponse, error) {
+	return c.imageRequest(ctx, r, variationsPath, r.options())
+}
+
+// imageRequest sends the image request to the path, options are used for the response pricing.
+func (c *Client) imageRequest(
+	ctx context.Context, r CommonRequest, path string, options *ImageRequest,
+) (*ImageResponse, error) {
+	body, err := commonRequest(ctx, c.httpClient, r, c.requestParams(path))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	response := &ImageResponse{}
+	if err = response.build(body); err != nil {
+		return nil, err
+	}
+
+	response.model, response.size, response.quality = options.variant()
+	return response, nil
+}
+
+// Transcription sends request to the audio transcriptions API.
+func (c *Client) Transcription(ctx context.Context, r *TranscriptionRequest) (*TranscriptionResponse, error) {
+	return c.audioRequest(ctx, r, transcriptionsPath, r.Model, r.ResponseFormat)
+}
+
+// Translation sends request to the audio translations API.
+func (c *Client) Translation(ctx context.Context, r *TranslationRequest) (*TranscriptionResponse, error) {
+	return c.audioRequest(ctx, r, translationsPath, r.Model, r.ResponseFormat)
+}
+
+// audioRequest sends the audio request to the path and reads the response of the format.
+func (c *Client) audioRequest(
+	ctx context.Context, r CommonRequest, path string, model Model, format TranscriptionFormat,
+) (*TranscriptionResponse, error) {
+	body, err := commonRequest(ctx, c.httpClient, r, c.requestParams(path))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	response := &TranscriptionResponse{}
+	if err = response.build(body, format); err != nil {
+		return nil, err
+	}
+
+	response.model = model
+	return response, nil
+}
+
+// Speech sends request to the text-to-speech API and returns the audio stream of the response format.
+// A caller must close the stream if no error.
+func (c *Client) Speech(ctx context.Context, r *SpeechRequest) (io.ReadCloser, error) {
+	return commonRequest(ctx, c.httpClient, r, c.requestParams(speechPath))
+}
+
+// Embeddings sends request to the embeddings API.
+func (c *Client) Embeddings(ctx context.Context, e *EmbeddingRequest) (*EmbeddingResponse, error) {
+	body, err := commonRequest(ctx, c.httpClient, e, c.requestParams(embeddingsPath))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	response := &EmbeddingResponse{}
+	if err = response.build(body); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ListModels returns models available for the API, it is useful for discovery of local servers models.
+func (c *Client) ListModels(ctx context.Context) ([]ModelObject, error) {
+	body, err := commonRequest(ctx, c.httpClient, &modelsRequest{}, c.requestParams(modelsPath))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	response := &modelsResponse{}
+	if err = json.NewDecoder(body).Decode(response); err != nil {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("failed to decode models: %w", err))
+	}
+
+	return response.Data, nil
+}
+
+// EmbeddingsBatch splits the request inputs to several requests according to the options,
+// sends them sequentially and returns one response with vectors in the order of inputs.
+func (c *Client) EmbeddingsBatch(
+	ctx context.Context, e *EmbeddingRequest, options BatchOptions,
+) (*EmbeddingResponse, error) {
+	if len(e.Input) == 0 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("input must not be empty"))
+	}
+
+	result := &EmbeddingResponse{Object: "list", Data: make([]Embedding, 0, len(e.Input))}
+
+	for _, batch := range options.split(e.Input) {
+		request := *e
+		request.Input = batch
+
+		response, err := c.Embeddings(ctx, &request)
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(response.Data); n != len(batch) {
+			return nil, errors.Join(ErrResponse, fmt.Errorf("expected %d embeddings, but gotten %d", len(batch), n))
+		}
+
+		offset := len(result.Data)
+		for _, item := range response.Data {
+			item.Index += offset
+			result.Data = append(result.Data, item)
+		}
+
+		result.Model = response.Model
+		result.Usage = result.Usage.Add(response.Usage)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package aoapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func clientServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expectedHeaders := map[string]string{
+			"Authorization":       "Bearer test",
+			"OpenAI-Organization": "test-org",
+			"OpenAI-Project":      "test-project",
+			"User-Agent":          "test-agent",
+			"X-Custom":            "custom",
+		}
+
+		for key, expected := range expectedHeaders {
+			if value := r.Header.Get(key); value != expected {
+				t.Errorf("failed %s header: %q", key, value)
+			}
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		var response string
+		switch r.URL.Path {
+		case "/v1" + completionsPath:
+			if s := string(data); !strings.Contains(s, `"model":"gpt-4o-mini"`) {
+				t.Errorf("default model is not set: %s", s)
+			}
+
+			response = `{"id":"test","object":"chat.completion","created":1677652288,` +
+				`"choices":[{"index":0,"message":{"content":"{\"city\":\"Paris\"}","role":"assistant"},` +
+				`"finish_reason":"length"}],"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`
+		case "/v1" + imagesPath:
+			response = `{"created":1677652288,"data":[{"url":"https://127.0.0.1/test1"}]}`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err = fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func newTestClient(s *httptest.Server, options ...Option) *Client {
+	defaults := []Option{
+		WithBaseURL(s.URL + "/v1/"),
+		WithBearer("test"),
+		WithOrganization("test-org"),
+		WithProject("test-project"),
+		WithUserAgent("test-agent"),
+		WithHeader("X-Custom", "custom"),
+		WithDefaultModel(ModelGPT4oMini),
+		WithStopMarker("..."),
+		WithHTTPClient(s.Client()),
+	}
+
+	return NewClient(append(defaults, options...)...)
+}
+
+func TestNewClient(t *testing.T) {
+	c := NewClient()
+
+	if c.baseURL != OpenAIBaseURL {
+		t.Errorf("unexpected base URL: %q", c.baseURL)
+	}
+
+	if c.httpClient != http.DefaultClient {
+		t.Error("unexpected HTTP client")
+	}
+
+	if url := c.requestParams(completionsPath).URL; url != OpenAICompletionURL {
+		t.Errorf("unexpected completion URL: %q", url)
+	}
+}
+
+func TestClient_Completion(t *testing.T) {
+	s := clientServer(t)
+	defer s.Close()
+
+	c := newTestClient(s)
+	request := &CompletionRequest{Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+
+	response, err := c.Completion(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.Model != "" {
+		t.Error("initial request must not be changed")
+	}
+
+	if r := response.String(); r != `{"city":"Paris"}...` {
+		t.Errorf("unexpected response: %q", r)
+	}
+}
+
+func TestClient_Image(t *testing.T) {
+	s := clientServer(t)
+	defer s.Close()
+
+	response, err := newTestClient(s).Image(context.Background(), &ImageRequest{Prompt: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := response.String(); r != "1. https://127.0.0.1/test1\n" {
+		t.Errorf("unexpected response: %q", r)
+	}
+}
+
+func TestClientCompletionInto(t *testing.T) {
+	s := clientServer(t)
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Messages:       []Message{{Role: RoleUser, Content: "Hello"}},
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject},
+	}
+
+	// the test server response is truncated by tokens limit
+	_, response, err := ClientCompletionInto[testWeather](context.Background(), newTestClient(s), request)
+	if err == nil || !strings.Contains(err.Error(), "content is truncated") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if response == nil || response.ID != "test" {
+		t.Errorf("unexpected response: %v", response)
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	s := clientServer(t)
+	defer s.Close()
+
+	c := newTestClient(s, WithBaseURL(s.URL+"/v2"))
+	request := &CompletionRequest{Model: ModelGPT4o, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+
+	if _, err := c.Completion(context.Background(), request); err == nil {
+		t.Error("expected error")
+	}
+}
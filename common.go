@@ -7,14 +7,63 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 // ErrorInfo is a struct of error information.
+// Status and Reason are set by Gemini API, its numeric code is converted to a string.
 type ErrorInfo struct {
 	Message string `json:"message"`
 	Type    string `json:"type"`
 	Param   string `json:"param"`
 	Code    string `json:"code"`
+	Status  string `json:"status,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Code can be a string or a number, Reason is taken from the first details item with it.
+func (e *ErrorInfo) UnmarshalJSON(b []byte) error {
+	aux := &struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Param   string          `json:"param"`
+		Code    json.RawMessage `json:"code"`
+		Status  string          `json:"status"`
+		Reason  string          `json:"reason"`
+		Details []struct {
+			Reason string `json:"reason"`
+		} `json:"details"`
+	}{}
+
+	if err := json.Unmarshal(b, aux); err != nil {
+		return errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	var code string
+	if len(aux.Code) > 0 && aux.Code[0] == '"' {
+		if err := json.Unmarshal(aux.Code, &code); err != nil {
+			return errors.Join(ErrUnmarshalJSON, err)
+		}
+	} else if raw := string(aux.Code); raw != "null" {
+		code = raw
+	}
+
+	*e = ErrorInfo{
+		Message: aux.Message,
+		Type:    aux.Type,
+		Param:   aux.Param,
+		Code:    code,
+		Status:  aux.Status,
+		Reason:  aux.Reason,
+	}
+
+	for i := 0; i < len(aux.Details) && e.Reason == ""; i++ {
+		e.Reason = aux.Details[i].Reason
+	}
+
+	return nil
 }
 
 // ResponseError is a struct of response error.
@@ -24,30 +73,95 @@ type ResponseError struct {
 
 // Error returns the error message.
 func (respErr *ResponseError) Error() string {
-	return fmt.Sprintf("type=%q, param=%q, code=%q: %s", respErr.E.Type, respErr.E.Param, respErr.E.Code, respErr.E.Message)
-}
+	msg := fmt.Sprintf("type=%q, param=%q, code=%q", respErr.E.Type, respErr.E.Param, respErr.E.Code)
 
-// build builds the error from the response. It always returns an error.
-func (respErr *ResponseError) build(reader io.ReadCloser, statusCode int) error {
-	defer func() {
-		_ = reader.Close()
-	}()
-
-	err := errors.Join(ErrResponse, fmt.Errorf("status code %d", statusCode))
+	if respErr.E.Status != "" {
+		msg += fmt.Sprintf(", status=%q", respErr.E.Status)
+	}
 
-	if e := json.NewDecoder(reader).Decode(respErr); e != nil {
-		return errors.Join(err, fmt.Errorf("failed unmarshal error: %w", e))
+	if respErr.E.Reason != "" {
+		msg += fmt.Sprintf(", reason=%q", respErr.E.Reason)
 	}
 
-	return errors.Join(err, respErr)
+	return msg + ": " + respErr.E.Message
 }
 
 // Params is a struct of API authentication and additional parameters.
+// Headers are added to every request, but they can not override authentication and content type headers.
+// If Provider is set, it defines the request URL and authentication instead of URL and Bearer.
 type Params struct {
 	Bearer       string
 	Organization string
+	Project      string
 	URL          string
 	StopMarker   string
+	UserAgent    string
+	Headers      http.Header
+	Retry        *RetryPolicy
+	Provider     Provider
+	path         string
+}
+
+// newRequest creates a new POST HTTP request to the URL of the params with common headers.
+func newRequest(
+	ctx context.Context, auth *Params, model Model, body io.Reader, contentType string,
+) (*http.Request, error) {
+	return newMethodRequest(ctx, http.MethodPost, auth, model, body, contentType)
+}
+
+// newMethodRequest creates a new HTTP request to the URL of the params with common headers.
+// Content type and authorization headers are not set if they are empty.
+func newMethodRequest(
+	ctx context.Context, method string, auth *Params, model Model, body io.Reader, contentType string,
+) (*http.Request, error) {
+	url := auth.URL
+
+	if auth.Provider != nil {
+		providerURL, err := auth.Provider.URL(auth.path, model)
+		if err != nil {
+			return nil, err
+		}
+
+		url = providerURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range auth.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	switch {
+	case auth.Provider != nil:
+		if err = auth.Provider.Authorize(ctx, req.Header); err != nil {
+			return nil, errors.Join(ErrAuthentication, err)
+		}
+	case auth.Bearer != "":
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", auth.Bearer))
+	}
+
+	if auth.Organization != "" {
+		req.Header.Set("OpenAI-Organization", auth.Organization)
+	}
+
+	if auth.Project != "" {
+		req.Header.Set("OpenAI-Project", auth.Project)
+	}
+
+	if auth.UserAgent != "" {
+		req.Header.Set("User-Agent", auth.UserAgent)
+	}
+
+	return req, nil
 }
 
 // CommonRequest is a common interface for all API requests.
@@ -55,24 +169,65 @@ type CommonRequest interface {
 	build(ctx context.Context, auth *Params) (*http.Request, error)
 }
 
-// commonRequest sends a request to the API and returns a body response.
+// replayableRequest is implemented by requests which bodies can not always be sent again.
+type replayableRequest interface {
+	replayable() bool
+}
+
+// commonRequest sends a request to the API and returns a body response as is,
+// so callers decode JSON payloads or stream binary ones, for example, audio data.
+// Failed requests are retried according to the params retry policy if the request body can be sent again.
 // A caller must close the response body if no error.
 func commonRequest(ctx context.Context, client *http.Client, cReq CommonRequest, p Params) (io.ReadCloser, error) {
-	request, err := cReq.build(ctx, &p)
-	if err != nil {
-		return nil, err
+	if r, ok := cReq.(replayableRequest); ok && !r.replayable() {
+		p.Retry = nil
+	}
+
+	for attempt := uint(1); ; attempt++ {
+		request, err := cReq.build(ctx, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		body, hint, err := sendRequest(client, request)
+		if err == nil {
+			return body, nil
+		}
+
+		if p.Retry == nil {
+			return nil, err
+		}
+
+		d, ok := p.Retry.delay(ctx, attempt, hint)
+		if !ok {
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+
+		if e := wait(ctx, d); e != nil {
+			return nil, &RetryError{Attempts: attempt, Err: errors.Join(err, e)}
+		}
 	}
+}
 
+// sendRequest sends the request and returns a body of successful response.
+// In case of error, it returns a hint for the next attempt.
+func sendRequest(client *http.Client, request *http.Request) (io.ReadCloser, retryHint, error) {
 	resp, err := client.Do(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		// the query can contain an API key, for example, of Gemini provider
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL, _, _ = strings.Cut(urlErr.URL, "?")
+		}
+
+		hint := retryHint{retryable: request.Context().Err() == nil && retryableError(err)}
+		return nil, hint, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		respErr := &ResponseError{}
-		// respErr.build closes the response body
-		return nil, respErr.build(resp.Body, resp.StatusCode)
+		// newAPIError closes the response body
+		return nil, newRetryHint(resp.StatusCode, resp.Header), newAPIError(resp)
 	}
 
-	return resp.Body, nil
+	return resp.Body, retryHint{}, nil
 }
@@ -0,0 +1,226 @@
+package aoapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContentPartType is a type of message content part.
+type ContentPartType string
+
+// Content part types.
+const (
+	ContentPartText       ContentPartType = "text"
+	ContentPartImageURL   ContentPartType = "image_url"
+	ContentPartInputAudio ContentPartType = "input_audio"
+	ContentPartFile       ContentPartType = "file"
+)
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c *ContentPartType) MarshalJSON() ([]byte, error) {
+	return marshalJSON(c, ContentPartText, ContentPartImageURL, ContentPartInputAudio, ContentPartFile)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *ContentPartType) UnmarshalJSON(b []byte) error {
+	return unMarshalJSON(c, b, ContentPartText, ContentPartImageURL, ContentPartInputAudio, ContentPartFile)
+}
+
+// ImageDetail is a type of image detail level.
+type ImageDetail string
+
+// Image detail levels.
+const (
+	ImageDetailAuto ImageDetail = "auto"
+	ImageDetailLow  ImageDetail = "low"
+	ImageDetailHigh ImageDetail = "high"
+)
+
+// allowed image MIME types for image content parts
+var imageMIMETypes = map[string]struct{}{
+	"image/png":  {},
+	"image/jpeg": {},
+	"image/gif":  {},
+	"image/webp": {},
+}
+
+// ImageURL is a struct of image URL or base64 data URL.
+type ImageURL struct {
+	URL    string      `json:"url"`
+	Detail ImageDetail `json:"detail,omitempty"`
+}
+
+// InputAudio is a struct of base64 encoded audio data.
+// Format is "wav" or "mp3".
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// FileContent is a struct of uploaded file ID or base64 encoded file data.
+type FileContent struct {
+	FileID   string `json:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+}
+
+// ContentPart is a struct of message content part.
+// Only one of Text, ImageURL, InputAudio or File is set according to the Type.
+type ContentPart struct {
+	Type       ContentPartType `json:"type"`
+	Text       string          `json:"text,omitempty"`
+	ImageURL   *ImageURL       `json:"image_url,omitempty"`
+	InputAudio *InputAudio     `json:"input_audio,omitempty"`
+	File       *FileContent    `json:"file,omitempty"`
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// ImageURLPart returns an image content part with the image URL.
+func ImageURLPart(url string, detail ImageDetail) ContentPart {
+	return ContentPart{Type: ContentPartImageURL, ImageURL: &ImageURL{URL: url, Detail: detail}}
+}
+
+// ImageDataPart returns an image content part with base64 data URL.
+// The image MIME type is detected by its content.
+func ImageDataPart(data []byte, detail ImageDetail) (ContentPart, error) {
+	mimeType := http.DetectContentType(data)
+
+	if _, ok := imageMIMETypes[mimeType]; !ok {
+		return ContentPart{}, errors.Join(ErrRequiredParam, fmt.Errorf("unsupported image type %q", mimeType))
+	}
+
+	return ImageURLPart(dataURL(mimeType, data), detail), nil
+}
+
+// ImageFilePart returns an image content part with base64 data URL of the local file.
+func ImageFilePart(path string, detail ImageDetail) (ContentPart, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return ContentPart{}, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	return ImageDataPart(data, detail)
+}
+
+// InputAudioPart returns an audio content part with base64 encoded data.
+func InputAudioPart(data []byte, format string) ContentPart {
+	return ContentPart{
+		Type:       ContentPartInputAudio,
+		InputAudio: &InputAudio{Data: base64.StdEncoding.EncodeToString(data), Format: format},
+	}
+}
+
+// FileDataPart returns a file content part with base64 data URL.
+func FileDataPart(filename string, data []byte) ContentPart {
+	return ContentPart{
+		Type: ContentPartFile,
+		File: &FileContent{Filename: filename, FileData: dataURL(http.DetectContentType(data), data)},
+	}
+}
+
+// FileIDPart returns a file content part with uploaded file ID.
+func FileIDPart(fileID string) ContentPart {
+	return ContentPart{Type: ContentPartFile, File: &FileContent{FileID: fileID}}
+}
+
+// dataURL returns base64 data URL.
+func dataURL(mimeType string, data []byte) string {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// messageContent returns the message content or its joined text parts.
+func messageContent(m *Message) string {
+	if m.Content != "" || len(m.Parts) == 0 {
+		return m.Content
+	}
+
+	texts := make([]string, 0, len(m.Parts))
+	for i := range m.Parts {
+		if m.Parts[i].Type == ContentPartText {
+			texts = append(texts, m.Parts[i].Text)
+		}
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+// parseDataURL returns MIME type and base64 data of the data URL.
+func parseDataURL(url string) (string, string, bool) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasPrefix(url, "data:") {
+		return "", "", false
+	}
+
+	mimeType, ok := strings.CutSuffix(meta, ";base64")
+	return mimeType, data, ok
+}
+
+// messageAlias is used to avoid recursion in Message JSON methods.
+type messageAlias Message
+
+// MarshalJSON implements the json.Marshaler interface.
+// The content is encoded as parts array if Parts is not empty, otherwise as a string.
+// Empty content of messages with tool calls is encoded as null.
+// ReasoningContent is omitted, because DeepSeek API rejects it in the input messages.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	var content any = m.Content
+
+	switch {
+	case len(m.Parts) > 0:
+		content = m.Parts
+	case m.Content == "" && len(m.ToolCalls) > 0:
+		content = nil
+	}
+
+	return json.Marshal(&struct {
+		Role             Role   `json:"role"`
+		Content          any    `json:"content"`
+		ReasoningContent string `json:"reasoning_content,omitempty"`
+		*messageAlias
+	}{Role: m.Role, Content: content, messageAlias: (*messageAlias)(m)})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// If the content is parts array, Content contains text parts joined by new lines as messageContent does.
+func (m *Message) UnmarshalJSON(b []byte) error {
+	aux := &struct {
+		Content json.RawMessage `json:"content"`
+		*messageAlias
+	}{messageAlias: (*messageAlias)(m)}
+
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+
+	m.Content, m.Parts = "", nil
+	content := strings.TrimSpace(string(aux.Content))
+
+	switch {
+	case content == "" || content == "null":
+		return nil
+	case strings.HasPrefix(content, "["):
+		if err := json.Unmarshal(aux.Content, &m.Parts); err != nil {
+			return errors.Join(ErrUnmarshalJSON, fmt.Errorf("invalid content parts: %w", err))
+		}
+
+		m.Content = messageContent(m)
+		return nil
+	default:
+		if err := json.Unmarshal(aux.Content, &m.Content); err != nil {
+			return errors.Join(ErrUnmarshalJSON, fmt.Errorf("invalid content: %w", err))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,228 @@
+package aoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pngHeader is a minimal PNG signature which is enough for MIME type detection.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestMessage_MarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		message  Message
+		expected string
+		err      error
+	}{
+		{
+			name:     "string",
+			message:  Message{Role: RoleUser, Content: "Hello"},
+			expected: `{"role":"user","content":"Hello"}`,
+		},
+		{
+			name: "parts",
+			message: Message{
+				Role:    RoleUser,
+				Content: "ignored",
+				Parts: []ContentPart{
+					TextPart("What is in this image?"),
+					ImageURLPart("https://127.0.0.1/image.png", ImageDetailLow),
+				},
+			},
+			expected: `{"role":"user","content":[{"type":"text","text":"What is in this image?"},` +
+				`{"type":"image_url","image_url":{"url":"https://127.0.0.1/image.png","detail":"low"}}]}`,
+		},
+		{
+			name: "tool calls",
+			message: Message{
+				Role:      RoleAssistant,
+				ToolCalls: []ToolCall{{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "f"}}},
+			},
+			expected: `{"role":"assistant","content":null,` +
+				`"tool_calls":[{"id":"call_1","type":"function","function":{"name":"f"}}]}`,
+		},
+		{
+			name:    "invalid role",
+			message: Message{Role: "unknown", Content: "Hello"},
+			err:     ErrMarshalJSON,
+		},
+		{
+			name:    "invalid part",
+			message: Message{Role: RoleUser, Parts: []ContentPart{{Type: "unknown"}}},
+			err:     ErrMarshalJSON,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(&tc.message)
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error: %v, got: %v", tc.err, err)
+				}
+				return
+			}
+
+			if tc.err != nil {
+				t.Fatalf("expected error, but got nil")
+			}
+
+			if s := string(data); s != tc.expected {
+				t.Fatalf("expected: %q, got: %q", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestMessage_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected Message
+		err      error
+	}{
+		{
+			name:     "string",
+			data:     `{"role":"assistant","content":"Hello"}`,
+			expected: Message{Role: RoleAssistant, Content: "Hello"},
+		},
+		{
+			name:     "null",
+			data:     `{"role":"assistant","content":null,"refusal":"no"}`,
+			expected: Message{Role: RoleAssistant, Refusal: "no"},
+		},
+		{
+			name: "parts",
+			data: `{"role":"user","content":[{"type":"text","text":"Hello,"},` +
+				`{"type":"image_url","image_url":{"url":"https://127.0.0.1/a.png"}},{"type":"text","text":"world"}]}`,
+			expected: Message{
+				Role:    RoleUser,
+				Content: "Hello,\nworld",
+				Parts: []ContentPart{
+					TextPart("Hello,"),
+					ImageURLPart("https://127.0.0.1/a.png", ""),
+					TextPart("world"),
+				},
+			},
+		},
+		{
+			name: "invalid parts",
+			data: `{"role":"user","content":[{"type":"unknown"}]}`,
+			err:  ErrUnmarshalJSON,
+		},
+		{
+			name: "invalid content",
+			data: `{"role":"user","content":{}}`,
+			err:  ErrUnmarshalJSON,
+		},
+		{
+			name: "invalid role",
+			data: `{"role":"unknown","content":"Hello"}`,
+			err:  ErrUnmarshalJSON,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var message Message
+			err := json.Unmarshal([]byte(tc.data), &message)
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error: %v, got: %v", tc.err, err)
+				}
+				return
+			}
+
+			if tc.err != nil {
+				t.Fatalf("expected error, but got nil")
+			}
+
+			if !reflect.DeepEqual(message, tc.expected) {
+				t.Fatalf("expected: %#v, got: %#v", tc.expected, message)
+			}
+		})
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	message := Message{Role: RoleUser, Parts: []ContentPart{TextPart("Hello"), TextPart("world")}}
+
+	data, err := json.Marshal(&message)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded Message
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if content := messageContent(&message); decoded.Content != content {
+		t.Errorf("expected %q, got %q", content, decoded.Content)
+	}
+}
+
+func TestImageFilePart(t *testing.T) {
+	dir := t.TempDir()
+
+	imagePath := filepath.Join(dir, "image.png")
+	if err := os.WriteFile(imagePath, pngHeader, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	textPath := filepath.Join(dir, "image.txt")
+	if err := os.WriteFile(textPath, []byte("not an image"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	part, err := ImageFilePart(imagePath, ImageDetailHigh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if part.Type != ContentPartImageURL || part.ImageURL.Detail != ImageDetailHigh {
+		t.Errorf("unexpected part: %#v", part)
+	}
+
+	if prefix := "data:image/png;base64,iVBORw0KGgo"; !strings.HasPrefix(part.ImageURL.URL, prefix) {
+		t.Errorf("expected prefix %q, got %q", prefix, part.ImageURL.URL)
+	}
+
+	if _, err = ImageFilePart(textPath, ImageDetailAuto); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+
+	if _, err = ImageFilePart(filepath.Join(dir, "not_found.png"), ImageDetailAuto); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %v, got %v", os.ErrNotExist, err)
+	}
+}
+
+func TestAudioAndFileParts(t *testing.T) {
+	audio := InputAudioPart([]byte("audio"), "wav")
+	if audio.Type != ContentPartInputAudio || audio.InputAudio.Data != "YXVkaW8=" || audio.InputAudio.Format != "wav" {
+		t.Errorf("unexpected audio part: %#v", audio)
+	}
+
+	file := FileDataPart("doc.pdf", []byte("%PDF-1.4"))
+	if file.Type != ContentPartFile || file.File.FileData != "data:application/pdf;base64,JVBERi0xLjQ=" {
+		t.Errorf("unexpected file part: %#v", file.File)
+	}
+
+	data, err := json.Marshal(&Message{Role: RoleUser, Parts: []ContentPart{FileIDPart("file-1")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"role":"user","content":[{"type":"file","file":{"file_id":"file-1"}}]}`
+	if s := string(data); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
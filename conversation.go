@@ -0,0 +1,319 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// summaryPrompt is a system prompt for the conversation summarization.
+const summaryPrompt = "Summarize the following conversation briefly. " +
+	"Keep names, facts, decisions and open questions which are needed to continue it."
+
+// outputReserve is a divisor of the context window for the reserved output tokens,
+// it is used if the model max output tokens are not less than its context window.
+const outputReserve = 4
+
+// summaryPrefix is a prefix of the system message with the conversation summary.
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// TrimStrategy reduces the conversation history until fits returns true.
+// The history does not contain pinned messages, the last message should be kept.
+// The history can start with a system message with the summary of earlier messages,
+// it is stored separately by the conversation, so strategies should keep it ahead of other messages.
+type TrimStrategy func(ctx context.Context, history []Message, fits func([]Message) bool) ([]Message, error)
+
+// ConversationOption is a function to set conversation options.
+type ConversationOption func(*Conversation)
+
+// WithTokenBudget sets the maximum number of prompt tokens.
+// Zero or negative value disables trimming.
+func WithTokenBudget(tokens int) ConversationOption {
+	return func(c *Conversation) {
+		c.budget = tokens
+	}
+}
+
+// WithTrimStrategy sets the history trimming strategy, DropOldest is used by default.
+func WithTrimStrategy(strategy TrimStrategy) ConversationOption {
+	return func(c *Conversation) {
+		c.strategy = strategy
+	}
+}
+
+// Conversation is a multi-turn chat history.
+// System messages are pinned and never trimmed, other messages are trimmed
+// by the strategy to fit the token budget. A summary of trimmed messages is kept ahead of the history.
+// It is safe for concurrent use, messages can be added while the history is trimmed.
+type Conversation struct {
+	mu       sync.Mutex
+	trimMu   sync.Mutex // only one trim is running, so history is only appended during it
+	model    Model
+	budget   int
+	strategy TrimStrategy
+	pinned   []Message
+	summary  []Message // zero or one summary message
+	history  []Message
+}
+
+// NewConversation returns a new conversation for the model.
+// The default token budget is the model context window minus its max output tokens,
+// if max output tokens fill the whole window, outputReserve part of it is left for the output.
+// The budget is not limited for unknown models.
+func NewConversation(model Model, options ...ConversationOption) *Conversation {
+	c := &Conversation{model: model, strategy: DropOldest}
+
+	if info, ok := LookupModel(model); ok {
+		switch {
+		case info.ContextWindow > info.MaxTokens:
+			c.budget = int(info.ContextWindow - info.MaxTokens)
+		case info.ContextWindow > 0:
+			c.budget = int(info.ContextWindow - info.ContextWindow/outputReserve)
+		}
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Model returns the conversation model.
+func (c *Conversation) Model() Model {
+	return c.model
+}
+
+// Add appends messages to the conversation, system and developer messages are pinned.
+func (c *Conversation) Add(messages ...Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, m := range messages {
+		if m.Role.isInstruction() {
+			c.pinned = append(c.pinned, m)
+		} else {
+			c.history = append(c.history, m)
+		}
+	}
+}
+
+// AddUser appends a user message to the conversation.
+func (c *Conversation) AddUser(content string) {
+	c.Add(Message{Role: RoleUser, Content: content})
+}
+
+// AddResponse appends the message of the first response choice to the conversation.
+func (c *Conversation) AddResponse(r *CompletionResponse) {
+	if r != nil && len(r.Choices) > 0 {
+		c.Add(r.Choices[0].Message)
+	}
+}
+
+// History returns copies of all pinned, summary and not trimmed messages.
+func (c *Conversation) History() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Concat(c.pinned, c.summary, c.history)
+}
+
+// Tokens returns the number of prompt tokens of the current history.
+func (c *Conversation) Tokens() int {
+	return CountTokens(c.History(), c.model)
+}
+
+// Messages trims the history according to the token budget and returns messages for the next request.
+// It returns ErrContextLengthExceeded if the pinned and the last messages do not fit the budget.
+// The conversation is not locked while the strategy works, messages added meanwhile are kept after the trimmed ones.
+func (c *Conversation) Messages(ctx context.Context) ([]Message, error) {
+	c.trimMu.Lock()
+	defer c.trimMu.Unlock()
+
+	c.mu.Lock()
+	pinned, history := slices.Clone(c.pinned), slices.Concat(c.summary, c.history)
+	added := len(c.history)
+	c.mu.Unlock()
+
+	trimmed, err := c.trim(ctx, pinned, history)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.summary, trimmed = splitSummary(trimmed)
+	c.history = append(trimmed, c.history[added:]...)
+
+	return slices.Concat(c.pinned, c.summary, c.history), nil
+}
+
+// trim reduces the history by the strategy if it does not fit the budget with the pinned messages.
+func (c *Conversation) trim(ctx context.Context, pinned, history []Message) ([]Message, error) {
+	if c.budget <= 0 {
+		return history, nil
+	}
+
+	fits := func(history []Message) bool {
+		return CountTokens(slices.Concat(pinned, history), c.model) <= c.budget
+	}
+
+	if fits(history) {
+		return history, nil
+	}
+
+	history, err := c.strategy(ctx, history, fits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trim conversation: %w", err)
+	}
+
+	if !fits(history) {
+		return nil, errors.Join(
+			ErrContextLengthExceeded,
+			fmt.Errorf("conversation does not fit the budget of %d tokens", c.budget),
+		)
+	}
+
+	return history, nil
+}
+
+// Send appends the user message, sends the conversation using the client
+// and appends the response message.
+func (c *Conversation) Send(ctx context.Context, client *Client, content string) (*CompletionResponse, error) {
+	c.AddUser(content)
+
+	messages, err := c.Messages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Completion(ctx, &CompletionRequest{Model: c.model, Messages: messages})
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddResponse(resp)
+	return resp, nil
+}
+
+// skipToolResults removes leading tool messages whose assistant tool calls were removed.
+func skipToolResults(history []Message) []Message {
+	for len(history) > 0 && history[0].Role == RoleTool {
+		history = history[1:]
+	}
+
+	return history
+}
+
+// splitSummary returns the leading summary message and other messages of the history.
+// Instruction messages are always pinned, so a system message in the history can only be a summary.
+func splitSummary(history []Message) ([]Message, []Message) {
+	if len(history) > 0 && history[0].Role == RoleSystem {
+		return history[:1], history[1:]
+	}
+
+	return nil, history
+}
+
+// dropOldest removes the oldest messages until the history fits, the last message is kept.
+// The summary is removed only if the last message does not fit with it.
+func dropOldest(history []Message, fits func([]Message) bool) []Message {
+	summary, history := splitSummary(history)
+
+	for len(history) > 1 && !fits(slices.Concat(summary, history)) {
+		history = skipToolResults(history[1:])
+	}
+
+	if fits(slices.Concat(summary, history)) {
+		return slices.Concat(summary, history)
+	}
+
+	return history
+}
+
+// DropOldest is a trim strategy which removes the oldest messages.
+func DropOldest(_ context.Context, history []Message, fits func([]Message) bool) ([]Message, error) {
+	return dropOldest(history, fits), nil
+}
+
+// SlidingWindow returns a trim strategy which keeps only the last size messages,
+// and removes the oldest of them if they still do not fit.
+func SlidingWindow(size int) TrimStrategy {
+	return func(_ context.Context, history []Message, fits func([]Message) bool) ([]Message, error) {
+		summary, history := splitSummary(history)
+
+		if size > 0 && len(history) > size {
+			history = skipToolResults(history[len(history)-size:])
+		}
+
+		return dropOldest(slices.Concat(summary, history), fits), nil
+	}
+}
+
+// Summarize returns a trim strategy which replaces all messages except the last keep ones
+// by a system message with their summary generated by the model, the previous summary is included into it.
+// If the history still does not fit, the oldest messages are removed.
+func Summarize(client *Client, model Model, keep int) TrimStrategy {
+	return func(ctx context.Context, history []Message, fits func([]Message) bool) ([]Message, error) {
+		summary, history := splitSummary(history)
+		split := max(len(history)-max(keep, 1), 0)
+		for split < len(history) && history[split].Role == RoleTool {
+			split++ // tool results are summarized with their calls
+		}
+
+		if split == 0 || split == len(history) {
+			return dropOldest(slices.Concat(summary, history), fits), nil
+		}
+
+		var transcript strings.Builder
+		for i := range summary {
+			transcript.WriteString(strings.TrimPrefix(summary[i].Content, summaryPrefix) + "\n")
+		}
+
+		for i := range history[:split] {
+			transcript.WriteString(transcriptLine(&history[i]))
+		}
+
+		request := &CompletionRequest{
+			Model: model,
+			Messages: []Message{
+				{Role: RoleSystem, Content: summaryPrompt},
+				{Role: RoleUser, Content: transcript.String()},
+			},
+		}
+
+		resp, err := client.Completion(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize conversation: %w", err)
+		}
+
+		if len(resp.Choices) == 0 {
+			return nil, errors.Join(ErrResponse, fmt.Errorf("summary response has no choices"))
+		}
+
+		message := Message{Role: RoleSystem, Content: summaryPrefix + resp.Choices[0].Message.Content}
+		return dropOldest(append([]Message{message}, history[split:]...), fits), nil
+	}
+}
+
+// transcriptLine returns the message text as a line of the conversation transcript.
+func transcriptLine(m *Message) string {
+	texts := []string{m.Content}
+
+	for i := range m.Parts {
+		if m.Parts[i].Type == ContentPartText {
+			texts = append(texts, m.Parts[i].Text)
+		}
+	}
+
+	for i := range m.ToolCalls {
+		f := m.ToolCalls[i].Function
+		texts = append(texts, fmt.Sprintf("call %s(%s)", f.Name, f.Arguments))
+	}
+
+	return fmt.Sprintf("%s: %s\n", m.Role, strings.TrimSpace(strings.Join(texts, " ")))
+}
@@ -0,0 +1,378 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// conversationServer returns completions with the content "reply to <last message content>",
+// or "summary" for the summarization requests.
+// All received requests are saved to the requests.
+func conversationServer(t *testing.T, requests *[]CompletionRequest) *httptest.Server {
+	var mu sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request CompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		*requests = append(*requests, request)
+		mu.Unlock()
+
+		reply := "reply to " + request.Messages[len(request.Messages)-1].Content
+		if request.Messages[0].Content == summaryPrompt {
+			reply = "summary"
+		}
+
+		content, err := json.Marshal(reply)
+		if err != nil {
+			t.Error(err)
+		}
+
+		response := fmt.Sprintf(`{"id":"test","object":"chat.completion","created":1677652288,`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":%s},"finish_reason":"stop"}],`+
+			`"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`, content)
+
+		if _, err = fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func testHistory() []Message {
+	return []Message{
+		{Role: RoleSystem, Content: "You are a helpful assistant."},
+		{Role: RoleUser, Content: "first question"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "1", Type: ToolTypeFunction, Function: FunctionCall{Name: "f"}}}},
+		{Role: RoleTool, Content: "tool result", ToolCallID: "1"},
+		{Role: RoleAssistant, Content: "first answer"},
+		{Role: RoleUser, Content: "second question"},
+	}
+}
+
+func TestNewConversation(t *testing.T) {
+	c := NewConversation(ModelGPT4oMini)
+	info, _ := LookupModel(ModelGPT4oMini)
+
+	if expected := int(info.ContextWindow - info.MaxTokens); c.budget != expected {
+		t.Errorf("expected budget %d, got %d", expected, c.budget)
+	}
+
+	// max output tokens of gpt-4 are equal to its context window
+	if c = NewConversation(ModelGPT4); c.budget != 6144 {
+		t.Errorf("unexpected gpt-4 budget %d", c.budget)
+	}
+
+	if c = NewConversation("unknown-model"); c.budget != 0 || c.Model() != "unknown-model" {
+		t.Errorf("unexpected conversation: %#v", c)
+	}
+
+	if c = NewConversation(ModelGPT4, WithTokenBudget(10)); c.budget != 10 {
+		t.Errorf("unexpected budget %d", c.budget)
+	}
+}
+
+func TestConversationMessages(t *testing.T) {
+	history := testHistory()
+	pinned := history[:1]
+
+	testCases := []struct {
+		name     string
+		strategy TrimStrategy
+		budget   int
+		expected []Message
+	}{
+		{
+			name:     "no budget",
+			strategy: DropOldest,
+			expected: history,
+		},
+		{
+			name:     "enough budget",
+			strategy: DropOldest,
+			budget:   CountTokens(history, ModelGPT4),
+			expected: history,
+		},
+		{
+			name:     "drop oldest",
+			strategy: DropOldest,
+			budget:   CountTokens(append(pinned[:1:1], history[4:]...), ModelGPT4),
+			expected: append(pinned[:1:1], history[4:]...),
+		},
+		{
+			name:     "drop tool results",
+			strategy: DropOldest,
+			budget:   CountTokens(append(pinned[:1:1], history[3:]...), ModelGPT4),
+			expected: append(pinned[:1:1], history[4:]...),
+		},
+		{
+			name:     "sliding window",
+			strategy: SlidingWindow(3),
+			budget:   1,
+			expected: nil,
+		},
+		{
+			name:     "sliding window size",
+			strategy: SlidingWindow(3),
+			budget:   CountTokens(history, ModelGPT4) - 1,
+			expected: append(pinned[:1:1], history[4:]...),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewConversation(ModelGPT4, WithTokenBudget(tc.budget), WithTrimStrategy(tc.strategy))
+			c.Add(testHistory()...)
+
+			messages, err := c.Messages(context.Background())
+			if tc.expected == nil {
+				if !errors.Is(err, ErrContextLengthExceeded) {
+					t.Errorf("expected %v, got %v", ErrContextLengthExceeded, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(messages, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, messages)
+			}
+
+			if history := c.History(); !reflect.DeepEqual(history, tc.expected) {
+				t.Errorf("history is not trimmed: %v", history)
+			}
+		})
+	}
+}
+
+func TestConversationSend(t *testing.T) {
+	var requests []CompletionRequest
+
+	s := conversationServer(t, &requests)
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	c := NewConversation(ModelGPT4oMini)
+	c.Add(Message{Role: RoleSystem, Content: "Be brief."})
+
+	for _, content := range []string{"one", "two"} {
+		resp, err := c.Send(context.Background(), client, content)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s := resp.String(); s != "reply to "+content {
+			t.Errorf("unexpected response %q", s)
+		}
+	}
+
+	if n := len(requests); n != 2 {
+		t.Fatalf("unexpected requests number %d", n)
+	}
+
+	if n := len(requests[1].Messages); n != 4 {
+		t.Errorf("unexpected messages number %d", n)
+	}
+
+	expected := []string{"Be brief.", "one", "reply to one", "two", "reply to two"}
+	history := c.History()
+	contents := make([]string, len(history))
+
+	for i := range history {
+		contents[i] = history[i].Content
+	}
+
+	if !reflect.DeepEqual(contents, expected) {
+		t.Errorf("expected %v, got %v", expected, contents)
+	}
+
+	if n := c.Tokens(); n != CountTokens(history, ModelGPT4oMini) {
+		t.Errorf("unexpected tokens %d", n)
+	}
+
+	// not fitted conversation is not sent
+	c = NewConversation(ModelGPT4oMini, WithTokenBudget(1))
+	if _, err := c.Send(context.Background(), client, "three"); !errors.Is(err, ErrContextLengthExceeded) {
+		t.Errorf("expected %v, got %v", ErrContextLengthExceeded, err)
+	}
+
+	if n := len(requests); n != 2 {
+		t.Errorf("unexpected requests number %d", n)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var requests []CompletionRequest
+
+	s := conversationServer(t, &requests)
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	history := testHistory()
+	history[1].Content = "first question with details"
+
+	expectedTranscript := "user: first question with details\nassistant: call f()\ntool: tool result\n"
+	expected := []Message{
+		history[0],
+		{Role: RoleSystem, Content: summaryPrefix + "summary"},
+		history[4],
+		history[5],
+	}
+
+	// 3 messages should be kept, but tool results are summarized with their call
+	c := NewConversation(
+		ModelGPT4,
+		WithTokenBudget(CountTokens(expected, ModelGPT4)),
+		WithTrimStrategy(Summarize(client, ModelGPT4oMini, 3)),
+	)
+	c.Add(history...)
+
+	messages, err := c.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(requests); n != 1 {
+		t.Fatalf("unexpected requests number %d", n)
+	}
+
+	if r := requests[0]; r.Model != ModelGPT4oMini || r.Messages[1].Content != expectedTranscript {
+		t.Errorf("unexpected summary request: %#v", r)
+	}
+
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected %v, got %v", expected, messages)
+	}
+
+	if !strings.HasPrefix(c.History()[1].Content, summaryPrefix) {
+		t.Error("summary is not saved")
+	}
+}
+
+func TestSummarizeFailed(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"Internal error"}}`, http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	history := testHistory()
+
+	c := NewConversation(
+		ModelGPT4,
+		WithTokenBudget(CountTokens(history, ModelGPT4)-1),
+		WithTrimStrategy(Summarize(client, ModelGPT4oMini, 1)),
+	)
+	c.Add(history...)
+
+	if _, err := c.Messages(context.Background()); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	if h := c.History(); !reflect.DeepEqual(h, history) {
+		t.Errorf("history is changed: %v", h)
+	}
+}
+
+func TestSummarizeAgain(t *testing.T) {
+	var requests []CompletionRequest
+
+	s := conversationServer(t, &requests)
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	history := testHistory()
+	history[1].Content = "first question with details"
+	summary := Message{Role: RoleSystem, Content: summaryPrefix + "summary"}
+	next := []Message{
+		{Role: RoleAssistant, Content: "second answer"},
+		{Role: RoleUser, Content: "third question"},
+	}
+
+	c := NewConversation(
+		ModelGPT4,
+		WithTokenBudget(CountTokens([]Message{history[0], summary, history[4], history[5]}, ModelGPT4)),
+		WithTrimStrategy(Summarize(client, ModelGPT4oMini, 2)),
+	)
+	c.Add(history...)
+
+	if _, err := c.Messages(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.Add(next...)
+
+	messages, err := c.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(requests); n != 2 {
+		t.Fatalf("unexpected requests number %d", n)
+	}
+
+	// the previous summary is included into the new one instead of being dropped
+	expectedTranscript := "summary\nassistant: first answer\nuser: second question\n"
+	if content := requests[1].Messages[1].Content; content != expectedTranscript {
+		t.Errorf("unexpected transcript %q", content)
+	}
+
+	expected := append([]Message{history[0], summary}, next...)
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected %v, got %v", expected, messages)
+	}
+}
+
+func TestConversationAddWhileTrimming(t *testing.T) {
+	var (
+		c       *Conversation
+		history = testHistory()
+		added   = Message{Role: RoleUser, Content: "added while trimming"}
+	)
+
+	strategy := func(ctx context.Context, history []Message, fits func([]Message) bool) ([]Message, error) {
+		done := make(chan struct{})
+
+		go func() {
+			c.Add(added) // the conversation is not locked during trimming
+			close(done)
+		}()
+		<-done
+
+		return DropOldest(ctx, history, fits)
+	}
+
+	c = NewConversation(
+		ModelGPT4,
+		WithTokenBudget(CountTokens(append(history[:1:1], history[4:]...), ModelGPT4)),
+		WithTrimStrategy(strategy),
+	)
+	c.Add(history...)
+
+	messages, err := c.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := append(history[:1:1], history[4], history[5], added)
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected %v, got %v", expected, messages)
+	}
+
+	if h := c.History(); !reflect.DeepEqual(h, expected) {
+		t.Errorf("unexpected history: %v", h)
+	}
+}
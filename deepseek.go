@@ -0,0 +1,23 @@
+package aoapi
+
+import "strings"
+
+// deepSeekRequest is a chat completion request of DeepSeek API,
+// it uses "max_tokens" parameter instead of "max_completion_tokens".
+type deepSeekRequest struct {
+	*CompletionRequest
+	MaxTokens uint `json:"max_tokens,omitempty"`
+}
+
+// isDeepSeek returns true if the model is served by DeepSeek API.
+func (m Model) isDeepSeek() bool {
+	return strings.HasPrefix(string(m), "deepseek")
+}
+
+// deepSeek returns a copy of the request for DeepSeek API.
+func (c *CompletionRequest) deepSeek() *deepSeekRequest {
+	request := *c
+	request.MaxTokens = 0
+
+	return &deepSeekRequest{CompletionRequest: &request, MaxTokens: c.MaxTokens}
+}
@@ -0,0 +1,126 @@
+package aoapi
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDeepSeekRequestMarshal(t *testing.T) {
+	temperature := float32(0.5)
+	messages := []Message{
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi", ReasoningContent: "greeting"},
+		{Role: RoleUser, Content: "How are you?"},
+	}
+
+	testCases := []struct {
+		name     string
+		model    Model
+		expected string
+	}{
+		{
+			name:  "chat",
+			model: ModelDeepSeekChat,
+			expected: `{"model":"deepseek-chat","messages":[{"role":"user","content":"Hello"},` +
+				`{"role":"assistant","content":"Hi"},{"role":"user","content":"How are you?"}],` +
+				`"temperature":0.5,"max_tokens":100}`,
+		},
+		{
+			name:  "reasoner",
+			model: ModelDeepSeekReasoner,
+			expected: `{"model":"deepseek-reasoner","messages":[{"role":"user","content":"Hello"},` +
+				`{"role":"assistant","content":"Hi"},{"role":"user","content":"How are you?"}],"max_tokens":100}`,
+		},
+		{
+			name:  "openai",
+			model: ModelGPT4oMini,
+			expected: `{"model":"gpt-4o-mini","messages":[{"role":"user","content":"Hello"},` +
+				`{"role":"assistant","content":"Hi"},{"role":"user","content":"How are you?"}],` +
+				`"max_completion_tokens":100,"temperature":0.5}`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := &CompletionRequest{Model: tc.model, Messages: messages, MaxTokens: 100, Temperature: &temperature}
+
+			body, err := request.marshal()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := string(data); s != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, s)
+			}
+
+			if request.MaxTokens != 100 || request.Temperature == nil {
+				t.Error("initial request must not be changed")
+			}
+		})
+	}
+}
+
+func TestDeepSeekResponse(t *testing.T) {
+	body := `{"id":"test","object":"chat.completion","created":1677652288,"model":"deepseek-reasoner",` +
+		`"choices":[{"index":0,"message":{"role":"assistant","content":"Fine","reasoning_content":"Think"},` +
+		`"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":6,"total_tokens":16,` +
+		`"prompt_cache_hit_tokens":8,"prompt_cache_miss_tokens":2}}`
+
+	response := &CompletionResponse{}
+	if err := response.build(strings.NewReader(body)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m := response.Choices[0].Message; m.Content != "Fine" || m.ReasoningContent != "Think" {
+		t.Errorf("unexpected message: %#v", m)
+	}
+
+	usage := Usage{
+		PromptTokens:          10,
+		CompletionTokens:      6,
+		TotalTokens:           16,
+		PromptCacheHitTokens:  8,
+		PromptCacheMissTokens: 2,
+	}
+	if response.Usage != usage {
+		t.Errorf("expected %v, got %v", usage, response.Usage)
+	}
+}
+
+func TestDeepSeekStream(t *testing.T) {
+	const body = `data: {"id":"test","created":1677652288,"choices":[{"index":0,` +
+		`"delta":{"role":"assistant","content":null,"reasoning_content":"Th"}}]}
+
+data: {"id":"test","choices":[{"index":0,"delta":{"content":null,"reasoning_content":"ink"}}]}
+
+data: {"id":"test","choices":[{"index":0,"delta":{"content":"Fine"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	s := streamServer(t, body)
+	defer s.Close()
+
+	request := &CompletionRequest{Model: ModelDeepSeekReasoner, Messages: []Message{{Role: RoleUser, Content: "Hi"}}}
+	stream, err := CompletionStream(context.Background(), s.Client(), request, Params{URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response, err := stream.Response()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m := response.Choices[0].Message; m.Content != "Fine" || m.ReasoningContent != "Think" {
+		t.Errorf("unexpected message: %#v", m)
+	}
+}
@@ -0,0 +1,258 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+)
+
+const (
+	// OpenAIEmbeddingURL is the default URL for the OpenAI embeddings API.
+	OpenAIEmbeddingURL = "https://api.openai.com/v1/embeddings"
+
+	// MaxEmbeddingInputs is the maximum number of inputs in one embedding request.
+	MaxEmbeddingInputs = 2048
+
+	// MaxEmbeddingTokens is the maximum number of tokens of all inputs in one embedding request.
+	MaxEmbeddingTokens = 300_000
+)
+
+// EncodingFormat is a type of embedding vector encoding format.
+type EncodingFormat string
+
+// Embedding encoding formats.
+const (
+	EncodingFormatFloat  EncodingFormat = "float"
+	EncodingFormatBase64 EncodingFormat = "base64"
+)
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e *EncodingFormat) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e, EncodingFormatFloat, EncodingFormatBase64)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *EncodingFormat) UnmarshalJSON(b []byte) error {
+	return unMarshalJSON(e, b, EncodingFormatFloat, EncodingFormatBase64)
+}
+
+// EmbeddingRequest is a struct of embedding request.
+// Dimensions is supported only by text-embedding-3 and later models.
+type EmbeddingRequest struct {
+	Model          Model          `json:"model"`
+	Input          []string       `json:"input"`
+	Dimensions     uint           `json:"dimensions,omitempty"`
+	EncodingFormat EncodingFormat `json:"encoding_format,omitempty"`
+	User           string         `json:"user,omitempty"`
+}
+
+func (e *EmbeddingRequest) marshal() (io.Reader, error) {
+	if e.Model == "" {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model must not be empty"))
+	}
+
+	if _, known := LookupModel(e.Model); known && !e.Model.Has(CapabilityEmbedding) {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model %q is not allowed for embedding requests", e.Model))
+	}
+
+	if len(e.Input) == 0 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("input must not be empty"))
+	}
+
+	if n := len(e.Input); n > MaxEmbeddingInputs {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("inputs limit is %d, but gotten %d", MaxEmbeddingInputs, n))
+	}
+
+	for i, input := range e.Input {
+		if input == "" {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("input %d must not be empty", i))
+		}
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+func (e *EmbeddingRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	body, err := e.marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return newRequest(ctx, auth, e.Model, body, "application/json")
+}
+
+// Embedding is a struct of embedding vector.
+// Base64 encoded vectors are decoded to Embedding too.
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *Embedding) UnmarshalJSON(b []byte) error {
+	type embeddingAlias Embedding
+
+	aux := &struct {
+		Embedding json.RawMessage `json:"embedding"`
+		*embeddingAlias
+	}{embeddingAlias: (*embeddingAlias)(e)}
+
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+
+	if len(aux.Embedding) == 0 || aux.Embedding[0] != '"' {
+		return json.Unmarshal(aux.Embedding, &e.Embedding)
+	}
+
+	var encoded string
+	if err := json.Unmarshal(aux.Embedding, &encoded); err != nil {
+		return errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	vector, err := decodeEmbedding(encoded)
+	if err != nil {
+		return errors.Join(ErrUnmarshalJSON, err)
+	}
+
+	e.Embedding = vector
+	return nil
+}
+
+// decodeEmbedding decodes base64 string of little-endian float32 values.
+func decodeEmbedding(encoded string) ([]float32, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid base64 embedding length %d", len(data))
+	}
+
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+
+	return vector, nil
+}
+
+// EmbeddingResponse is a struct of embedding response.
+// Data is sorted by index.
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []Embedding `json:"data"`
+	Model  Model       `json:"model"`
+	Usage  Usage       `json:"usage"`
+}
+
+func (er *EmbeddingResponse) build(body io.Reader) error {
+	if err := json.NewDecoder(body).Decode(&er); err != nil {
+		return errors.Join(ErrResponse, fmt.Errorf("failed to unmarshal embedding response: %w", err))
+	}
+
+	if len(er.Data) == 0 {
+		return errors.Join(ErrResponse, fmt.Errorf("empty embedding response"))
+	}
+
+	slices.SortFunc(er.Data, func(a, b Embedding) int {
+		return a.Index - b.Index
+	})
+
+	return nil
+}
+
+// Vectors returns embedding vectors in the order of inputs.
+func (er *EmbeddingResponse) Vectors() [][]float32 {
+	vectors := make([][]float32, len(er.Data))
+
+	for i := range er.Data {
+		vectors[i] = er.Data[i].Embedding
+	}
+
+	return vectors
+}
+
+// BatchOptions is a struct of embedding batch limits.
+// Zero values are replaced by MaxEmbeddingInputs and MaxEmbeddingTokens.
+// Tokens is a function to count input tokens, by default it is a rough estimation.
+type BatchOptions struct {
+	MaxInputs int
+	MaxTokens int
+	Tokens    func(input string) int
+}
+
+// estimateTokens returns a pessimistic tokens number estimation.
+// It is the UTF-8 bytes number, because byte level BPE tokens are never shorter than one byte.
+func estimateTokens(input string) int {
+	return len(input)
+}
+
+// split splits inputs to batches according to the options.
+func (o BatchOptions) split(inputs []string) [][]string {
+	var (
+		batches   [][]string
+		start     int
+		tokens    int
+		maxInputs = o.MaxInputs
+		maxTokens = o.MaxTokens
+		count     = o.Tokens
+	)
+
+	if maxInputs <= 0 || maxInputs > MaxEmbeddingInputs {
+		maxInputs = MaxEmbeddingInputs
+	}
+
+	if maxTokens <= 0 {
+		maxTokens = MaxEmbeddingTokens
+	}
+
+	if count == nil {
+		count = estimateTokens
+	}
+
+	for i, input := range inputs {
+		n := count(input)
+
+		if i > start && (i-start >= maxInputs || tokens+n > maxTokens) {
+			batches = append(batches, inputs[start:i])
+			start, tokens = i, 0
+		}
+
+		tokens += n
+	}
+
+	if start < len(inputs) {
+		batches = append(batches, inputs[start:])
+	}
+
+	return batches
+}
+
+// Embeddings sends request to the embeddings API.
+func Embeddings(ctx context.Context, client *http.Client, e *EmbeddingRequest, p Params) (*EmbeddingResponse, error) {
+	return newParamsClient(client, p).Embeddings(ctx, e)
+}
+
+// EmbeddingsBatch splits the request inputs to several requests according to the options,
+// sends them sequentially and returns one response with vectors in the order of inputs.
+func EmbeddingsBatch(
+	ctx context.Context, client *http.Client, e *EmbeddingRequest, p Params, options BatchOptions,
+) (*EmbeddingResponse, error) {
+	return newParamsClient(client, p).EmbeddingsBatch(ctx, e, options)
+}
@@ -0,0 +1,303 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// encodeTestEmbedding returns base64 string of little-endian float32 values.
+func encodeTestEmbedding(values ...float32) string {
+	data := make([]byte, 0, len(values)*4)
+
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// embeddingServer returns embeddings with the input length as the first vector value.
+// The data items are returned in the reversed order.
+func embeddingServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		var request EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+
+		data := make([]string, 0, len(request.Input))
+		for i, input := range request.Input {
+			value := float32(len(input))
+			vector := fmt.Sprintf("[%v,0.5]", value)
+
+			if request.EncodingFormat == EncodingFormatBase64 {
+				vector = fmt.Sprintf("%q", encodeTestEmbedding(value, 0.5))
+			}
+
+			data = append(data, fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":%s}`, i, vector))
+		}
+
+		slices.Reverse(data)
+		w.Header().Set("Content-Type", "application/json")
+		response := fmt.Sprintf(
+			`{"object":"list","data":[%s],"model":"%s","usage":{"prompt_tokens":%d,"total_tokens":%d}}`,
+			strings.Join(data, ","), request.Model, len(request.Input), len(request.Input),
+		)
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestEmbeddingRequestMarshal(t *testing.T) {
+	testCases := []struct {
+		name      string
+		request   EmbeddingRequest
+		errString string
+		expected  string
+	}{
+		{
+			name: "valid",
+			request: EmbeddingRequest{
+				Model:          ModelTextEmbedding3Small,
+				Input:          []string{"a", "b"},
+				Dimensions:     256,
+				EncodingFormat: EncodingFormatBase64,
+			},
+			expected: `{"model":"text-embedding-3-small","input":["a","b"],"dimensions":256,"encoding_format":"base64"}`,
+		},
+		{
+			name:      "empty model",
+			request:   EmbeddingRequest{Input: []string{"a"}},
+			errString: "model must not be empty",
+		},
+		{
+			name:      "chat model",
+			request:   EmbeddingRequest{Model: ModelGPT4o, Input: []string{"a"}},
+			errString: `model "gpt-4o" is not allowed for embedding requests`,
+		},
+		{
+			name:      "empty input",
+			request:   EmbeddingRequest{Model: ModelTextEmbedding3Small},
+			errString: "input must not be empty",
+		},
+		{
+			name:      "empty input item",
+			request:   EmbeddingRequest{Model: ModelTextEmbedding3Small, Input: []string{"a", ""}},
+			errString: "input 1 must not be empty",
+		},
+		{
+			name:      "too many inputs",
+			request:   EmbeddingRequest{Model: ModelTextEmbedding3Small, Input: make([]string, MaxEmbeddingInputs+1)},
+			errString: "inputs limit is 2048, but gotten 2049",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := tc.request.marshal()
+			if err != nil {
+				if !errors.Is(err, ErrRequiredParam) {
+					t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+				}
+
+				if e := err.Error(); !strings.Contains(e, tc.errString) || tc.errString == "" {
+					t.Errorf("expected %q, got %q", tc.errString, e)
+				}
+				return
+			}
+
+			buf := new(bytes.Buffer)
+			if _, err = buf.ReadFrom(reader); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := buf.String(); s != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestEmbeddings(t *testing.T) {
+	var calls atomic.Int32
+
+	s := embeddingServer(t, &calls)
+	defer s.Close()
+
+	for _, format := range []EncodingFormat{"", EncodingFormatFloat, EncodingFormatBase64} {
+		t.Run(string(format), func(t *testing.T) {
+			request := &EmbeddingRequest{
+				Model:          ModelTextEmbedding3Small,
+				Input:          []string{"a", "bb", "ccc"},
+				EncodingFormat: format,
+			}
+
+			response, err := Embeddings(context.Background(), s.Client(), request, Params{URL: s.URL})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := [][]float32{{1, 0.5}, {2, 0.5}, {3, 0.5}}
+			if vectors := response.Vectors(); !reflect.DeepEqual(vectors, expected) {
+				t.Errorf("expected %v, got %v", expected, vectors)
+			}
+
+			if response.Model != ModelTextEmbedding3Small || response.Usage.TotalTokens != 3 {
+				t.Errorf("unexpected response: %#v", response)
+			}
+		})
+	}
+}
+
+func TestEmbeddingsFailed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "empty", body: `{"data":[]}`, expected: "empty embedding response"},
+		{name: "invalid json", body: `{"data":`, expected: "failed to unmarshal embedding response"},
+		{name: "invalid base64", body: `{"data":[{"embedding":"!"}]}`, expected: "invalid base64 embedding"},
+		{name: "invalid length", body: `{"data":[{"embedding":"AAA="}]}`, expected: "invalid base64 embedding length"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := fmt.Fprint(w, tc.body); err != nil {
+					t.Error(err)
+				}
+			}))
+			defer s.Close()
+
+			request := &EmbeddingRequest{Model: ModelTextEmbedding3Small, Input: []string{"a"}}
+			_, err := Embeddings(context.Background(), s.Client(), request, Params{URL: s.URL})
+
+			if !errors.Is(err, ErrResponse) {
+				t.Errorf("expected %v, got %v", ErrResponse, err)
+			}
+
+			if e := err.Error(); !strings.Contains(e, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, e)
+			}
+		})
+	}
+}
+
+func TestBatchOptions_split(t *testing.T) {
+	inputs := []string{"a", "bb", "ccc", "dddd", "e"}
+
+	testCases := []struct {
+		name     string
+		options  BatchOptions
+		expected [][]string
+	}{
+		{
+			name:     "default",
+			expected: [][]string{inputs},
+		},
+		{
+			name:     "inputs",
+			options:  BatchOptions{MaxInputs: 2},
+			expected: [][]string{{"a", "bb"}, {"ccc", "dddd"}, {"e"}},
+		},
+		{
+			name:     "tokens",
+			options:  BatchOptions{MaxTokens: 4, Tokens: func(s string) int { return len(s) }},
+			expected: [][]string{{"a", "bb"}, {"ccc"}, {"dddd"}, {"e"}},
+		},
+		{
+			name:     "large input",
+			options:  BatchOptions{MaxTokens: 2, Tokens: func(s string) int { return len(s) }},
+			expected: [][]string{{"a"}, {"bb"}, {"ccc"}, {"dddd"}, {"e"}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if batches := tc.options.split(inputs); !reflect.DeepEqual(batches, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, batches)
+			}
+		})
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	// every CJK rune is 3 bytes and it is encoded by 1-3 tokens
+	inputs := []string{"你好世界", "日本語"}
+	expected := [][]string{{"你好世界"}, {"日本語"}}
+
+	if batches := (BatchOptions{MaxTokens: 20}).split(inputs); !reflect.DeepEqual(batches, expected) {
+		t.Errorf("expected %v, got %v", expected, batches)
+	}
+
+	if n := estimateTokens(""); n != 0 {
+		t.Errorf("unexpected tokens %d", n)
+	}
+}
+
+func TestEmbeddingsBatch(t *testing.T) {
+	var calls atomic.Int32
+
+	s := embeddingServer(t, &calls)
+	defer s.Close()
+
+	inputs := make([]string, 7)
+	expected := make([][]float32, len(inputs))
+
+	for i := range inputs {
+		inputs[i] = strings.Repeat("x", i+1)
+		expected[i] = []float32{float32(i + 1), 0.5}
+	}
+
+	request := &EmbeddingRequest{Model: ModelTextEmbedding3Large, Input: inputs}
+	options := BatchOptions{MaxInputs: 3}
+
+	response, err := EmbeddingsBatch(context.Background(), s.Client(), request, Params{URL: s.URL}, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := calls.Load(); n != 3 {
+		t.Errorf("unexpected calls number: %d", n)
+	}
+
+	if vectors := response.Vectors(); !reflect.DeepEqual(vectors, expected) {
+		t.Errorf("expected %v, got %v", expected, vectors)
+	}
+
+	for i, item := range response.Data {
+		if item.Index != i {
+			t.Errorf("unexpected index %d, expected %d", item.Index, i)
+		}
+	}
+
+	if response.Usage.TotalTokens != 7 || response.Model != ModelTextEmbedding3Large {
+		t.Errorf("unexpected response: %#v", response)
+	}
+
+	request.Input = nil
+	if _, err = EmbeddingsBatch(context.Background(), s.Client(), request, Params{URL: s.URL}, options); err == nil {
+		t.Error("expected error")
+	}
+}
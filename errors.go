@@ -0,0 +1,158 @@
+package aoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize is the maximum size of the error response body which is read.
+const maxErrorBodySize = 1 << 20
+
+var (
+	// ErrRateLimited is an error that occurs when the API rate limit or quota is exceeded.
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrAuthentication is an error that occurs when the API key is invalid or missing.
+	ErrAuthentication = errors.New("authentication failed")
+
+	// ErrContextLengthExceeded is an error that occurs when the request exceeds the model context window.
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+
+	// ErrContentFilter is an error that occurs when the request is rejected by the content filter.
+	ErrContentFilter = errors.New("content filter")
+
+	// ErrBudgetExceeded is an error that occurs when a request exceeds the spend or token budget.
+	ErrBudgetExceeded = errors.New("budget exceeded")
+)
+
+// RateLimit is a struct of rate limits from the response headers.
+// Unknown values are zero.
+type RateLimit struct {
+	LimitRequests     int64
+	LimitTokens       int64
+	RemainingRequests int64
+	RemainingTokens   int64
+	ResetRequests     time.Duration
+	ResetTokens       time.Duration
+}
+
+// newRateLimit parses x-ratelimit-* headers.
+func newRateLimit(header http.Header) RateLimit {
+	parseInt := func(key string) int64 {
+		value, _ := strconv.ParseInt(header.Get(key), 10, 64)
+		return value
+	}
+
+	parseDuration := func(key string) time.Duration {
+		value, _ := time.ParseDuration(header.Get(key))
+		return value
+	}
+
+	return RateLimit{
+		LimitRequests:     parseInt("x-ratelimit-limit-requests"),
+		LimitTokens:       parseInt("x-ratelimit-limit-tokens"),
+		RemainingRequests: parseInt("x-ratelimit-remaining-requests"),
+		RemainingTokens:   parseInt("x-ratelimit-remaining-tokens"),
+		ResetRequests:     parseDuration("x-ratelimit-reset-requests"),
+		ResetTokens:       parseDuration("x-ratelimit-reset-tokens"),
+	}
+}
+
+// APIError is an error of failed API response.
+// Response is nil and Body contains the raw response if it is not a JSON error.
+// It matches ErrResponse and other API errors with errors.Is.
+type APIError struct {
+	StatusCode int
+	RequestID  string
+	RateLimit  RateLimit
+	Header     http.Header
+	Response   *ResponseError
+	Body       []byte
+	decodeErr  error
+}
+
+// newAPIError reads and closes the response body and returns the API error.
+func newAPIError(resp *http.Response) error {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-request-id"),
+		RateLimit:  newRateLimit(resp.Header),
+		Header:     resp.Header,
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		apiErr.decodeErr = fmt.Errorf("failed read error: %w", err)
+		return apiErr
+	}
+
+	respErr := &ResponseError{}
+	if err = json.Unmarshal(body, respErr); err != nil {
+		apiErr.Body = body
+		apiErr.decodeErr = fmt.Errorf("failed unmarshal error: %w", err)
+		return apiErr
+	}
+
+	apiErr.Response = respErr
+	return apiErr
+}
+
+// Error returns the error message.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%v\nstatus code %d", ErrResponse, e.StatusCode)
+
+	switch {
+	case e.Response != nil:
+		return msg + "\n" + e.Response.Error()
+	case e.decodeErr != nil:
+		return msg + "\n" + e.decodeErr.Error()
+	default:
+		return msg
+	}
+}
+
+// Unwrap returns the decoded response error if it exists.
+func (e *APIError) Unwrap() error {
+	if e.Response == nil {
+		return nil
+	}
+
+	return e.Response
+}
+
+// Is reports whether the error matches the target error.
+func (e *APIError) Is(target error) bool {
+	var info ErrorInfo
+	if e.Response != nil {
+		info = e.Response.E
+	}
+
+	switch target {
+	case ErrResponse:
+		return true
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests || info.Status == "RESOURCE_EXHAUSTED"
+	case ErrAuthentication:
+		// Gemini API returns 400 status for invalid API keys
+		return e.StatusCode == http.StatusUnauthorized || info.Code == "invalid_api_key" ||
+			info.Status == "UNAUTHENTICATED" || info.Reason == "API_KEY_INVALID"
+	case ErrContextLengthExceeded:
+		return info.Code == "context_length_exceeded" ||
+			strings.Contains(info.Message, "maximum context length") ||
+			strings.Contains(info.Message, "exceeds the maximum number of tokens")
+	case ErrContentFilter:
+		return info.Code == "content_filter" || info.Code == "content_policy_violation"
+	}
+
+	return false
+}
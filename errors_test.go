@@ -0,0 +1,162 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIError(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		body       string
+		sentinels  []error
+		message    string
+	}{
+		{
+			name:       "rate limit",
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`,
+			sentinels:  []error{ErrResponse, ErrRateLimited},
+			message: "failed response\nstatus code 429\n" +
+				`type="requests", param="", code="rate_limit_exceeded": Rate limit reached`,
+		},
+		{
+			name:       "authentication",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"error":{"message":"Incorrect API key provided","code":"invalid_api_key"}}`,
+			sentinels:  []error{ErrResponse, ErrAuthentication},
+			message: "failed response\nstatus code 401\n" +
+				`type="", param="", code="invalid_api_key": Incorrect API key provided`,
+		},
+		{
+			name:       "context length",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"message":"This model's maximum context length is 4097 tokens."}}`,
+			sentinels:  []error{ErrResponse, ErrContextLengthExceeded},
+			message: "failed response\nstatus code 400\n" +
+				`type="", param="", code="": This model's maximum context length is 4097 tokens.`,
+		},
+		{
+			name:       "content filter",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"message":"Your request was rejected","code":"content_policy_violation"}}`,
+			sentinels:  []error{ErrResponse, ErrContentFilter},
+			message: "failed response\nstatus code 400\n" +
+				`type="", param="", code="content_policy_violation": Your request was rejected`,
+		},
+		{
+			name:       "gemini invalid key",
+			statusCode: http.StatusBadRequest,
+			body: `{"error":{"code":400,"message":"API key not valid.","status":"INVALID_ARGUMENT",` +
+				`"details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"API_KEY_INVALID"}]}}`,
+			sentinels: []error{ErrResponse, ErrAuthentication},
+			message: "failed response\nstatus code 400\n" +
+				`type="", param="", code="400", status="INVALID_ARGUMENT", reason="API_KEY_INVALID": API key not valid.`,
+		},
+		{
+			name:       "gemini context length",
+			statusCode: http.StatusBadRequest,
+			body: `{"error":{"code":400,"status":"INVALID_ARGUMENT","message":` +
+				`"The input token count (1200000) exceeds the maximum number of tokens allowed (1048576)."}}`,
+			sentinels: []error{ErrResponse, ErrContextLengthExceeded},
+			message: "failed response\nstatus code 400\n" +
+				`type="", param="", code="400", status="INVALID_ARGUMENT": ` +
+				`The input token count (1200000) exceeds the maximum number of tokens allowed (1048576).`,
+		},
+		{
+			name:       "not json",
+			statusCode: http.StatusBadGateway,
+			body:       "<html>Bad Gateway</html>",
+			sentinels:  []error{ErrResponse},
+			message: "failed response\nstatus code 502\n" +
+				"failed unmarshal error: invalid character '<' looking for beginning of value",
+		},
+	}
+
+	allSentinels := []error{ErrResponse, ErrRateLimited, ErrAuthentication, ErrContextLengthExceeded, ErrContentFilter}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("x-request-id", "req-123")
+				w.Header().Set("x-ratelimit-limit-requests", "60")
+				w.Header().Set("x-ratelimit-remaining-requests", "0")
+				w.Header().Set("x-ratelimit-reset-tokens", "1.5s")
+				w.WriteHeader(tc.statusCode)
+
+				if _, err := fmt.Fprint(w, tc.body); err != nil {
+					t.Error(err)
+				}
+			}))
+			defer s.Close()
+
+			request := &CompletionRequest{Model: ModelGPT4oMini, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+			_, err := Completion(context.Background(), s.Client(), request, Params{URL: s.URL})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected API error, got %T: %v", err, err)
+			}
+
+			if apiErr.StatusCode != tc.statusCode || apiErr.RequestID != "req-123" {
+				t.Errorf("unexpected API error: %#v", apiErr)
+			}
+
+			expectedLimit := RateLimit{LimitRequests: 60, ResetTokens: 1500 * time.Millisecond}
+			if apiErr.RateLimit != expectedLimit {
+				t.Errorf("expected %v, got %v", expectedLimit, apiErr.RateLimit)
+			}
+
+			if e := err.Error(); e != tc.message {
+				t.Errorf("expected %q, got %q", tc.message, e)
+			}
+
+			for _, sentinel := range allSentinels {
+				expected := false
+				for _, s := range tc.sentinels {
+					expected = expected || s == sentinel
+				}
+
+				if errors.Is(err, sentinel) != expected {
+					t.Errorf("unexpected errors.Is result %v for %v", !expected, sentinel)
+				}
+			}
+
+			var respErr *ResponseError
+			if isJSON := apiErr.Body == nil; errors.As(err, &respErr) != isJSON {
+				t.Errorf("unexpected response error: %v", respErr)
+			}
+
+			if apiErr.Body != nil && string(apiErr.Body) != tc.body {
+				t.Errorf("unexpected raw body: %q", apiErr.Body)
+			}
+		})
+	}
+}
+
+func TestAPIErrorRetry(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, `{"error":{"message":"Rate limit reached"}}`, http.StatusTooManyRequests)
+	}))
+	defer s.Close()
+
+	request := &CompletionRequest{Model: ModelGPT4oMini, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	_, err := Completion(context.Background(), s.Client(), request, Params{URL: s.URL, Retry: NewRetryPolicy(2)})
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected %v, got %v", ErrRateLimited, err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
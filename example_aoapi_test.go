@@ -107,3 +107,102 @@ func ExampleImage() {
 	// https://127.0.0.1/test1
 	// https://127.0.0.1/test2
 }
+
+func gptStreamServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"test","created":1677652288,"choices":[{"index":0,"delta":{"role":"assistant","content":"Hallo, "}}]}`,
+			`{"id":"test","created":1677652288,"choices":[{"index":0,"delta":{"content":"wie geht es dir?"}}]}`,
+			`{"id":"test","created":1677652288,"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`[DONE]`,
+		}
+
+		for _, chunk := range chunks {
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", chunk); err != nil {
+				panic(err)
+			}
+		}
+	}))
+}
+
+func ExampleCompletionStream() {
+	var key = os.Getenv("OPENAI_API_KEY")
+
+	// test ChatGPT server, for production use: "https://api.openai.com/v1/chat/completions"
+	server := gptStreamServer()
+	defer server.Close()
+	params := aoapi.Params{Bearer: key, URL: server.URL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+	request := &aoapi.CompletionRequest{
+		Model:    aoapi.ModelGPT4oMini,
+		Messages: []aoapi.Message{{Role: aoapi.RoleUser, Content: "Translate to German: Hello, how are you?"}},
+	}
+
+	stream, err := aoapi.CompletionStream(ctx, client, request, params)
+	if err != nil {
+		panic(err) // or handle error
+	}
+
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	for chunk, chunkErr := range stream.Chunks() {
+		if chunkErr != nil {
+			panic(chunkErr) // or handle error
+		}
+
+		for _, choice := range chunk.Choices {
+			fmt.Printf("%q\n", choice.Delta.Content)
+		}
+	}
+
+	// Output:
+	// "Hallo, "
+	// "wie geht es dir?"
+	// ""
+}
+
+func ExampleClient() {
+	// test ChatGPT server, for production use default base URL
+	server := gptCompletionServer()
+	defer server.Close()
+
+	client := aoapi.NewClient(
+		aoapi.WithBaseURL(server.URL),
+		aoapi.WithBearer(os.Getenv("OPENAI_API_KEY")),
+		aoapi.WithDefaultModel(aoapi.ModelGPT4oMini),
+		aoapi.WithStopMarker("..."),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	request := &aoapi.CompletionRequest{
+		Messages: []aoapi.Message{
+			{
+				Role:    aoapi.RoleSystem,
+				Content: "You are translator from English to German. Translate the following sentences.",
+			},
+			{
+				Role:    aoapi.RoleUser,
+				Content: "Hello, how are you? What are you doing?",
+			},
+		},
+	}
+
+	resp, err := client.Completion(ctx, request)
+	if err != nil {
+		panic(err) // or handle error
+	}
+
+	fmt.Println(resp.String())
+
+	// Output:
+	// Hallo, wie geht es dir? Was machst du?
+}
@@ -0,0 +1,454 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// GeminiBaseURL is the default base URL for the Google Gemini API.
+const GeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Gemini is a provider of the Google Gemini generateContent API.
+// Only chat completions without streaming are supported.
+// System messages are joined to the system instruction, the API key is sent as a query parameter.
+// LogitBias and User are ignored.
+type Gemini struct {
+	BaseURL string
+	APIKey  string
+}
+
+// geminiBlob is an inline data of content part.
+type geminiBlob struct {
+	MIMEType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// geminiFileData is an URI data of content part.
+type geminiFileData struct {
+	MIMEType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// geminiFunctionCall is a function call of the model.
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// geminiFunctionResponse is a result of the function call.
+type geminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// geminiPart is a content part, only one field is set.
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// geminiContent is a content of user or model.
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiGenerationConfig is a generation parameters.
+type geminiGenerationConfig struct {
+	Temperature        *float32        `json:"temperature,omitempty"`
+	TopP               *float32        `json:"topP,omitempty"`
+	MaxOutputTokens    uint            `json:"maxOutputTokens,omitempty"`
+	CandidateCount     *uint           `json:"candidateCount,omitempty"`
+	StopSequences      []string        `json:"stopSequences,omitempty"`
+	PresencePenalty    *float32        `json:"presencePenalty,omitempty"`
+	FrequencyPenalty   *float32        `json:"frequencyPenalty,omitempty"`
+	ResponseMIMEType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+// geminiFunctionDeclaration is a function tool definition.
+type geminiFunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+// geminiTool is a set of function declarations.
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+// geminiToolConfig is a function calling mode.
+type geminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"`
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
+}
+
+// geminiRequest is a request of the generateContent API.
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+}
+
+// geminiCandidate is a response candidate.
+type geminiCandidate struct {
+	Index        int           `json:"index"`
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+// geminiUsage is a tokens usage metadata.
+type geminiUsage struct {
+	PromptTokenCount        uint `json:"promptTokenCount"`
+	CandidatesTokenCount    uint `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      uint `json:"thoughtsTokenCount"`
+	CachedContentTokenCount uint `json:"cachedContentTokenCount"`
+	TotalTokenCount         uint `json:"totalTokenCount"`
+}
+
+// geminiResponse is a response of the generateContent API.
+type geminiResponse struct {
+	ResponseID    string            `json:"responseId"`
+	Candidates    []geminiCandidate `json:"candidates"`
+	UsageMetadata geminiUsage       `json:"usageMetadata"`
+}
+
+// geminiFinishReasons maps finish reasons, unknown reasons are FinishReasonStop.
+var geminiFinishReasons = map[string]FinishReason{
+	"MAX_TOKENS":         FinishReasonLength,
+	"SAFETY":             FinishReasonContentFilter,
+	"RECITATION":         FinishReasonContentFilter,
+	"BLOCKLIST":          FinishReasonContentFilter,
+	"PROHIBITED_CONTENT": FinishReasonContentFilter,
+	"SPII":               FinishReasonContentFilter,
+	"IMAGE_SAFETY":       FinishReasonContentFilter,
+}
+
+// URL returns the generateContent URL of the model with the API key query parameter.
+func (g *Gemini) URL(path string, model Model) (string, error) {
+	if path != completionsPath {
+		return "", errors.Join(ErrRequiredParam, fmt.Errorf("path %q is not supported by gemini provider", path))
+	}
+
+	baseURL := g.BaseURL
+	if baseURL == "" {
+		baseURL = GeminiBaseURL
+	}
+
+	query := url.Values{"key": {g.APIKey}}
+	return fmt.Sprintf(
+		"%s/models/%s:generateContent?%s",
+		strings.TrimRight(baseURL, "/"), url.PathEscape(string(model)), query.Encode(),
+	), nil
+}
+
+// Authorize checks the API key, it is sent as the URL query parameter.
+func (g *Gemini) Authorize(context.Context, http.Header) error {
+	if g.APIKey == "" {
+		return fmt.Errorf("gemini API key must not be empty")
+	}
+
+	return nil
+}
+
+// EncodeCompletion converts the chat completion request to the generateContent request.
+func (g *Gemini) EncodeCompletion(r *CompletionRequest) ([]byte, error) {
+	var (
+		request   geminiRequest
+		system    []geminiPart
+		functions = make(map[string]string) // tool call ID -> function name
+	)
+
+	if r.ReasoningEffort != "" || r.Verbosity != "" {
+		return nil, errors.Join(
+			ErrRequiredParam, fmt.Errorf("reasoning effort and verbosity are not supported by gemini provider"),
+		)
+	}
+
+	for i := range r.Messages {
+		m := &r.Messages[i]
+
+		if m.Role.isInstruction() {
+			system = append(system, geminiPart{Text: messageContent(m)})
+			continue
+		}
+
+		content, err := geminiEncodeMessage(m, functions)
+		if err != nil {
+			return nil, err
+		}
+
+		// consecutive contents of the same role are merged, for example, several function responses
+		if n := len(request.Contents); n > 0 && request.Contents[n-1].Role == content.Role {
+			request.Contents[n-1].Parts = append(request.Contents[n-1].Parts, content.Parts...)
+		} else {
+			request.Contents = append(request.Contents, content)
+		}
+	}
+
+	if len(system) > 0 {
+		request.SystemInstruction = &geminiContent{Parts: system}
+	}
+
+	config := &geminiGenerationConfig{
+		Temperature:      r.Temperature,
+		TopP:             r.TopP,
+		MaxOutputTokens:  r.MaxTokens,
+		CandidateCount:   r.N,
+		PresencePenalty:  r.PresencePenalty,
+		FrequencyPenalty: r.FrequencyPenalty,
+	}
+
+	if r.Stop != nil {
+		config.StopSequences = *r.Stop
+	}
+
+	if f := r.ResponseFormat; f != nil && f.Type != ResponseFormatText {
+		config.ResponseMIMEType = "application/json"
+
+		if f.JSONSchema != nil {
+			config.ResponseJSONSchema = f.JSONSchema.Schema
+		}
+	}
+
+	if !reflect.ValueOf(config).Elem().IsZero() {
+		request.GenerationConfig = config
+	}
+
+	request.Tools, request.ToolConfig = geminiEncodeTools(r)
+
+	data, err := json.Marshal(&request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal gemini request: %w", err)
+	}
+
+	return data, nil
+}
+
+// geminiEncodeMessage converts the message to user or model content.
+// Function names of the tool calls are saved to functions to use them in function responses.
+func geminiEncodeMessage(m *Message, functions map[string]string) (geminiContent, error) {
+	switch m.Role {
+	case RoleTool:
+		response := json.RawMessage(messageContent(m))
+		if !json.Valid(response) || !strings.HasPrefix(strings.TrimSpace(string(response)), "{") {
+			data, err := json.Marshal(map[string]string{"content": messageContent(m)})
+			if err != nil {
+				return geminiContent{}, fmt.Errorf("failed to marshal function response: %w", err)
+			}
+
+			response = data
+		}
+
+		part := geminiPart{FunctionResponse: &geminiFunctionResponse{
+			ID:       m.ToolCallID,
+			Name:     functions[m.ToolCallID],
+			Response: response,
+		}}
+
+		return geminiContent{Role: "user", Parts: []geminiPart{part}}, nil
+	case RoleUser, RoleAssistant:
+	default:
+		return geminiContent{}, errors.Join(
+			ErrRequiredParam, fmt.Errorf("role %q is not supported by gemini provider", m.Role),
+		)
+	}
+
+	content := geminiContent{Role: "user"}
+	if m.Role == RoleAssistant {
+		content.Role = "model"
+	}
+
+	if m.Content != "" && len(m.Parts) == 0 {
+		content.Parts = append(content.Parts, geminiPart{Text: m.Content})
+	}
+
+	for i := range m.Parts {
+		part, err := geminiEncodePart(&m.Parts[i])
+		if err != nil {
+			return geminiContent{}, err
+		}
+
+		content.Parts = append(content.Parts, part)
+	}
+
+	for _, call := range m.ToolCalls {
+		functions[call.ID] = call.Function.Name
+		content.Parts = append(content.Parts, geminiPart{FunctionCall: &geminiFunctionCall{
+			ID:   call.ID,
+			Name: call.Function.Name,
+			Args: json.RawMessage(call.Function.Arguments),
+		}})
+	}
+
+	return content, nil
+}
+
+// geminiEncodePart converts the message content part.
+func geminiEncodePart(part *ContentPart) (geminiPart, error) {
+	switch {
+	case part.Type == ContentPartText:
+		return geminiPart{Text: part.Text}, nil
+	case part.Type == ContentPartImageURL && part.ImageURL != nil:
+		return geminiEncodeData(part.ImageURL.URL), nil
+	case part.Type == ContentPartInputAudio && part.InputAudio != nil:
+		blob := &geminiBlob{MIMEType: "audio/" + part.InputAudio.Format, Data: part.InputAudio.Data}
+		return geminiPart{InlineData: blob}, nil
+	case part.Type == ContentPartFile && part.File != nil:
+		if part.File.FileData != "" {
+			return geminiEncodeData(part.File.FileData), nil
+		}
+
+		return geminiPart{FileData: &geminiFileData{FileURI: part.File.FileID}}, nil
+	}
+
+	return geminiPart{}, errors.Join(
+		ErrRequiredParam, fmt.Errorf("content part %q is not supported by gemini provider", part.Type),
+	)
+}
+
+// geminiEncodeData returns inline data part for data URL or file data part otherwise.
+func geminiEncodeData(dataURL string) geminiPart {
+	if mimeType, data, ok := parseDataURL(dataURL); ok {
+		return geminiPart{InlineData: &geminiBlob{MIMEType: mimeType, Data: data}}
+	}
+
+	fileData := &geminiFileData{FileURI: dataURL}
+	if u, err := url.Parse(dataURL); err == nil {
+		fileData.MIMEType = mime.TypeByExtension(path.Ext(u.Path))
+	}
+
+	return geminiPart{FileData: fileData}
+}
+
+// geminiEncodeTools converts function tools and the tool choice.
+func geminiEncodeTools(r *CompletionRequest) ([]geminiTool, *geminiToolConfig) {
+	if len(r.Tools) == 0 {
+		return nil, nil
+	}
+
+	tool := geminiTool{FunctionDeclarations: make([]geminiFunctionDeclaration, 0, len(r.Tools))}
+	for _, t := range r.Tools {
+		tool.FunctionDeclarations = append(tool.FunctionDeclarations, geminiFunctionDeclaration{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+
+	if r.ToolChoice == nil {
+		return []geminiTool{tool}, nil
+	}
+
+	config := &geminiToolConfig{}
+	switch {
+	case r.ToolChoice.Function != "":
+		config.FunctionCallingConfig.Mode = "ANY"
+		config.FunctionCallingConfig.AllowedFunctionNames = []string{r.ToolChoice.Function}
+	case r.ToolChoice.Mode == ToolChoiceNone:
+		config.FunctionCallingConfig.Mode = "NONE"
+	case r.ToolChoice.Mode == ToolChoiceRequired:
+		config.FunctionCallingConfig.Mode = "ANY"
+	default:
+		config.FunctionCallingConfig.Mode = "AUTO"
+	}
+
+	return []geminiTool{tool}, config
+}
+
+// DecodeCompletion converts the generateContent response to the chat completion response.
+func (g *Gemini) DecodeCompletion(body io.Reader) (*CompletionResponse, error) {
+	var response geminiResponse
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal gemini response: %w", err)
+	}
+
+	choices := make([]Choice, 0, len(response.Candidates))
+	for _, candidate := range response.Candidates {
+		choices = append(choices, geminiDecodeCandidate(&candidate))
+	}
+
+	usage := response.UsageMetadata
+	return &CompletionResponse{
+		ID:      response.ResponseID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Choices: choices,
+		Usage: Usage{
+			PromptTokens:     usage.PromptTokenCount,
+			CompletionTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+			TotalTokens:      usage.TotalTokenCount,
+			PromptTokensDetails: PromptTokensDetails{
+				CachedTokens: usage.CachedContentTokenCount,
+			},
+			CompletionTokensDetails: CompletionTokensDetails{
+				ReasoningTokens: usage.ThoughtsTokenCount,
+			},
+		},
+	}, nil
+}
+
+// geminiDecodeCandidate converts the response candidate to the choice.
+func geminiDecodeCandidate(candidate *geminiCandidate) Choice {
+	var (
+		message = Message{Role: RoleAssistant}
+		texts   []string
+	)
+
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d_%d", candidate.Index, len(message.ToolCalls))
+			}
+
+			arguments := string(part.FunctionCall.Args)
+			if arguments == "" {
+				arguments = "{}"
+			}
+
+			message.ToolCalls = append(message.ToolCalls, ToolCall{
+				ID:       id,
+				Type:     ToolTypeFunction,
+				Function: FunctionCall{Name: part.FunctionCall.Name, Arguments: arguments},
+			})
+		case part.Text != "" && !part.Thought:
+			texts = append(texts, part.Text)
+		}
+	}
+
+	message.Content = strings.Join(texts, "")
+
+	finishReason, ok := geminiFinishReasons[candidate.FinishReason]
+	switch {
+	case !ok && len(message.ToolCalls) > 0:
+		finishReason = FinishReasonToolCalls
+	case !ok:
+		finishReason = FinishReasonStop
+	}
+
+	return Choice{Index: candidate.Index, Message: message, FinishReason: finishReason}
+}
@@ -0,0 +1,249 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGeminiEncodeCompletion(t *testing.T) {
+	var (
+		temperature = float32(0.5)
+		n           = uint(2)
+		stop        = []string{"END"}
+	)
+
+	request := &CompletionRequest{
+		Model: ModelGemini25Flash,
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleUser, Parts: []ContentPart{
+				TextPart("What is here?"),
+				ImageURLPart("data:image/png;base64,iVBORw0K", ImageDetailLow),
+				ImageURLPart("https://localhost/a.png", ""),
+				{Type: ContentPartInputAudio, InputAudio: &InputAudio{Data: "UklGRg==", Format: "wav"}},
+			}},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{
+				{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "call_2", Type: ToolTypeFunction, Function: FunctionCall{Name: "time", Arguments: `{}`}},
+			}},
+			{Role: RoleTool, ToolCallID: "call_1", Content: `{"sky":"sunny"}`},
+			{Role: RoleTool, ToolCallID: "call_2", Content: "noon"},
+		},
+		MaxTokens:   100,
+		Temperature: &temperature,
+		N:           &n,
+		Stop:        &stop,
+		Tools: []Tool{
+			{Type: ToolTypeFunction, Function: Function{Name: "weather", Parameters: json.RawMessage(`{"type":"object"}`)}},
+		},
+		ToolChoice: &ToolChoice{Function: "weather"},
+		ResponseFormat: &ResponseFormat{
+			Type:       ResponseFormatJSONSchema,
+			JSONSchema: &JSONSchema{Name: "answer", Schema: json.RawMessage(`{"type":"object"}`)},
+		},
+	}
+
+	data, err := (&Gemini{}).EncodeCompletion(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"contents":[` +
+		`{"role":"user","parts":[{"text":"What is here?"},` +
+		`{"inlineData":{"mimeType":"image/png","data":"iVBORw0K"}},` +
+		`{"fileData":{"mimeType":"image/png","fileUri":"https://localhost/a.png"}},` +
+		`{"inlineData":{"mimeType":"audio/wav","data":"UklGRg=="}}]},` +
+		`{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"weather","args":{"city":"Paris"}}},` +
+		`{"functionCall":{"id":"call_2","name":"time","args":{}}}]},` +
+		`{"role":"user","parts":[{"functionResponse":{"id":"call_1","name":"weather","response":{"sky":"sunny"}}},` +
+		`{"functionResponse":{"id":"call_2","name":"time","response":{"content":"noon"}}}]}],` +
+		`"systemInstruction":{"parts":[{"text":"Be brief."}]},` +
+		`"generationConfig":{"temperature":0.5,"maxOutputTokens":100,"candidateCount":2,"stopSequences":["END"],` +
+		`"responseMimeType":"application/json","responseJsonSchema":{"type":"object"}},` +
+		`"tools":[{"functionDeclarations":[{"name":"weather","parametersJsonSchema":{"type":"object"}}]}],` +
+		`"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["weather"]}}}`
+
+	if s := string(data); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+
+	// minimal request has no generation config
+	request = &CompletionRequest{Model: ModelGemini25Flash, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	if data, err = (&Gemini{}).EncodeCompletion(request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := string(data); s != `{"contents":[{"role":"user","parts":[{"text":"Hello"}]}]}` {
+		t.Errorf("unexpected request %s", s)
+	}
+
+	request.Messages[0].Role = "unknown"
+	if _, err = (&Gemini{}).EncodeCompletion(request); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
+
+func TestGeminiEncodeDecodedMessage(t *testing.T) {
+	var message Message
+
+	data := `{"role":"user","content":[{"type":"text","text":"Hello"},` +
+		`{"type":"image_url","image_url":{"url":"https://localhost/a.png"}}]}`
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request := &CompletionRequest{Model: ModelGemini25Flash, Messages: []Message{message}}
+
+	body, err := (&Gemini{}).EncodeCompletion(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"contents":[{"role":"user","parts":[{"text":"Hello"},` +
+		`{"fileData":{"mimeType":"image/png","fileUri":"https://localhost/a.png"}}]}]}`
+
+	if s := string(body); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+}
+
+func TestGeminiDecodeCompletion(t *testing.T) {
+	body := `{"responseId":"resp-1","candidates":[` +
+		`{"index":0,"content":{"role":"model","parts":[{"text":"thinking","thought":true},{"text":"Hello"},` +
+		`{"text":" world"}]},"finishReason":"MAX_TOKENS"},` +
+		`{"index":1,"content":{"role":"model","parts":[{"functionCall":{"name":"weather","args":{"city":"Paris"}}}]},` +
+		`"finishReason":"STOP"},` +
+		`{"index":2,"content":{"parts":[]},"finishReason":"SAFETY"}],` +
+		`"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"thoughtsTokenCount":3,"totalTokenCount":18}}`
+
+	response, err := (&Gemini{}).DecodeCompletion(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Choice{
+		{Index: 0, Message: Message{Role: RoleAssistant, Content: "Hello world"}, FinishReason: FinishReasonLength},
+		{
+			Index: 1,
+			Message: Message{Role: RoleAssistant, ToolCalls: []ToolCall{{
+				ID:       "call_1_0",
+				Type:     ToolTypeFunction,
+				Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			FinishReason: FinishReasonToolCalls,
+		},
+		{Index: 2, Message: Message{Role: RoleAssistant}, FinishReason: FinishReasonContentFilter},
+	}
+
+	if !reflect.DeepEqual(response.Choices, expected) {
+		t.Errorf("expected %#v, got %#v", expected, response.Choices)
+	}
+
+	usage := Usage{
+		PromptTokens:            10,
+		CompletionTokens:        8,
+		TotalTokens:             18,
+		CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 3},
+	}
+	if response.Usage != usage {
+		t.Errorf("expected %v, got %v", usage, response.Usage)
+	}
+
+	if response.ID != "resp-1" {
+		t.Errorf("unexpected ID %q", response.ID)
+	}
+
+	if _, err = (&Gemini{}).DecodeCompletion(strings.NewReader("[")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGeminiClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.URL.Query().Get("key"); key != "test-key" && key != "invalid-key" {
+			t.Errorf("unexpected key %q", key)
+		}
+
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("unexpected authorization header %q", auth)
+		}
+
+		if r.URL.Query().Get("key") == "invalid-key" {
+			w.WriteHeader(http.StatusBadRequest)
+			response := `{"error":{"code":400,"message":"API key not valid.","status":"INVALID_ARGUMENT",` +
+				`"details":[{"reason":"API_KEY_INVALID"}]}}`
+
+			if _, err := fmt.Fprint(w, response); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+
+		var response string
+		switch r.URL.Path {
+		case "/v1beta/models/gemini-2.5-flash:generateContent":
+			response = `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]},"finishReason":"STOP"}],` +
+				`"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":6,"totalTokenCount":10}}`
+		case "/v1beta/models/gemini-2.5-pro:generateContent":
+			response = `{"candidates":[]}`
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	provider := &Gemini{BaseURL: s.URL + "/v1beta", APIKey: "test-key"}
+	client := NewClient(WithProvider(provider), WithHTTPClient(s.Client()), WithBearer("unused"))
+	ctx := context.Background()
+
+	request := &CompletionRequest{Model: ModelGemini25Flash, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	response, err := client.Completion(ctx, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := response.String(); s != "Hello" {
+		t.Errorf("unexpected response %q", s)
+	}
+
+	request.Model = ModelGemini25Pro
+	if _, err = client.Completion(ctx, request); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	failed := NewClient(WithProvider(&Gemini{BaseURL: s.URL}), WithHTTPClient(s.Client()))
+	if _, err = failed.Completion(ctx, request); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected %v, got %v", ErrAuthentication, err)
+	}
+
+	invalid := NewClient(WithProvider(&Gemini{BaseURL: s.URL, APIKey: "invalid-key"}), WithHTTPClient(s.Client()))
+	_, err = invalid.Completion(ctx, request)
+
+	var apiErr *APIError
+	if !errors.Is(err, ErrAuthentication) || !errors.As(err, &apiErr) {
+		t.Errorf("expected %v, got %v", ErrAuthentication, err)
+	} else if message := apiErr.Response.E.Message; message != "API key not valid." {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	// the API key is not leaked by transport errors
+	closed := NewClient(WithProvider(&Gemini{BaseURL: "http://127.0.0.1:1", APIKey: "test-key"}))
+	if _, err = closed.Completion(ctx, request); err == nil || strings.Contains(err.Error(), "test-key") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err = provider.URL(embeddingsPath, ModelGemini25Pro); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
@@ -3,11 +3,14 @@ package aoapi
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -16,22 +19,198 @@ type ImageSize string
 
 // Image sizes.
 const (
-	ImageSize256  ImageSize = "256x256"
-	ImageSize512  ImageSize = "512x512"
-	ImageSize1024 ImageSize = "1024x1024"
+	ImageSize256       ImageSize = "256x256"   // only for dall-e-2
+	ImageSize512       ImageSize = "512x512"   // only for dall-e-2
+	ImageSize1024      ImageSize = "1024x1024" // square image for all models
+	ImageSize1792x1024 ImageSize = "1792x1024" // only for dall-e-3
+	ImageSize1024x1792 ImageSize = "1024x1792" // only for dall-e-3
+	ImageSize1536x1024 ImageSize = "1536x1024" // only for gpt-image-1
+	ImageSize1024x1536 ImageSize = "1024x1536" // only for gpt-image-1
+	ImageSizeAuto      ImageSize = "auto"      // only for gpt-image-1
+)
+
+// ImageQuality is a type of image quality.
+type ImageQuality string
+
+// Image qualities.
+const (
+	ImageQualityStandard ImageQuality = "standard" // only for dall-e-2 and dall-e-3
+	ImageQualityHD       ImageQuality = "hd"       // only for dall-e-3
+	ImageQualityLow      ImageQuality = "low"      // only for gpt-image-1
+	ImageQualityMedium   ImageQuality = "medium"   // only for gpt-image-1
+	ImageQualityHigh     ImageQuality = "high"     // only for gpt-image-1
+	ImageQualityAuto     ImageQuality = "auto"     // only for gpt-image-1
+)
+
+// ImageStyle is a type of dall-e-3 image style.
+type ImageStyle string
+
+// Image styles.
+const (
+	ImageStyleVivid   ImageStyle = "vivid"
+	ImageStyleNatural ImageStyle = "natural"
+)
+
+// ImageResponseFormat is a type of dall-e image response format.
+// gpt-image-1 always returns base64 encoded images.
+type ImageResponseFormat string
+
+// Image response formats.
+const (
+	ImageResponseURL     ImageResponseFormat = "url"
+	ImageResponseB64JSON ImageResponseFormat = "b64_json"
+)
+
+// ImageBackground is a type of gpt-image-1 image background.
+type ImageBackground string
+
+// Image backgrounds, transparent one requires png or webp output format.
+const (
+	ImageBackgroundTransparent ImageBackground = "transparent"
+	ImageBackgroundOpaque      ImageBackground = "opaque"
+	ImageBackgroundAuto        ImageBackground = "auto"
+)
+
+// ImageOutputFormat is a type of gpt-image-1 output image format.
+type ImageOutputFormat string
+
+// Image output formats.
+const (
+	ImageOutputPNG  ImageOutputFormat = "png"
+	ImageOutputJPEG ImageOutputFormat = "jpeg"
+	ImageOutputWEBP ImageOutputFormat = "webp"
+)
+
+// ImageModeration is a type of gpt-image-1 content moderation level.
+type ImageModeration string
+
+// Image moderation levels.
+const (
+	ImageModerationLow  ImageModeration = "low"
+	ImageModerationAuto ImageModeration = "auto"
 )
 
 // ImageRequest is a struct of image request.
+// Only options supported by the model are allowed, see the constants comments.
+// OutputCompression is a percent from 0 to 100 for jpeg and webp output formats.
 type ImageRequest struct {
-	Model  Model     `json:"model,omitempty"`
-	Prompt string    `json:"prompt"`
-	N      uint      `json:"n,omitempty"`
-	Size   ImageSize `json:"size,omitempty"`
+	Model             Model               `json:"model,omitempty"`
+	Prompt            string              `json:"prompt"`
+	N                 uint                `json:"n,omitempty"`
+	Size              ImageSize           `json:"size,omitempty"`
+	Quality           ImageQuality        `json:"quality,omitempty"`
+	Style             ImageStyle          `json:"style,omitempty"`
+	ResponseFormat    ImageResponseFormat `json:"response_format,omitempty"`
+	Background        ImageBackground     `json:"background,omitempty"`
+	OutputFormat      ImageOutputFormat   `json:"output_format,omitempty"`
+	OutputCompression *uint               `json:"output_compression,omitempty"`
+	Moderation        ImageModeration     `json:"moderation,omitempty"`
+	User              string              `json:"user,omitempty"`
+}
+
+// imageModel describes options of the image model.
+type imageModel struct {
+	maxN      uint
+	sizes     []ImageSize
+	qualities []ImageQuality
+	styles    bool // dall-e-3 style
+	formats   bool // dall-e response format
+	gptImage  bool // gpt-image-1 background, output and moderation options
+}
+
+// imageModels contains options of known image models, other models are not validated.
+var imageModels = map[Model]imageModel{
+	ModelDalle2: {
+		maxN:      10,
+		sizes:     []ImageSize{ImageSize256, ImageSize512, ImageSize1024},
+		qualities: []ImageQuality{ImageQualityStandard},
+		formats:   true,
+	},
+	ModelDalle3: {
+		maxN:      1,
+		sizes:     []ImageSize{ImageSize1024, ImageSize1792x1024, ImageSize1024x1792},
+		qualities: []ImageQuality{ImageQualityStandard, ImageQualityHD},
+		styles:    true,
+		formats:   true,
+	},
+	ModelGPTImage1: {
+		maxN:      10,
+		sizes:     []ImageSize{ImageSize1024, ImageSize1536x1024, ImageSize1024x1536, ImageSizeAuto},
+		qualities: []ImageQuality{ImageQualityLow, ImageQualityMedium, ImageQualityHigh, ImageQualityAuto},
+		gptImage:  true,
+	},
+}
+
+// validateOptions checks that the request options are supported by the model.
+func (i *ImageRequest) validateOptions() error {
+	model := i.Model
+	if model == "" {
+		model = ModelDalle2
+	}
+
+	options, ok := imageModels[model]
+	if !ok {
+		return nil
+	}
+
+	var (
+		styles  = []ImageStyle{ImageStyleVivid, ImageStyleNatural}
+		formats = []ImageResponseFormat{ImageResponseURL, ImageResponseB64JSON}
+		output  = i.Background != "" || i.OutputFormat != "" || i.OutputCompression != nil || i.Moderation != ""
+		err     error
+	)
+
+	switch {
+	case i.N > options.maxN:
+		err = fmt.Errorf("number of images must not be greater than %d", options.maxN)
+	case i.Size != "" && !slices.Contains(options.sizes, i.Size):
+		err = fmt.Errorf("size %q is not supported", i.Size)
+	case i.Quality != "" && !slices.Contains(options.qualities, i.Quality):
+		err = fmt.Errorf("quality %q is not supported", i.Quality)
+	case i.Style != "" && (!options.styles || !slices.Contains(styles, i.Style)):
+		err = fmt.Errorf("style %q is not supported", i.Style)
+	case i.ResponseFormat != "" && (!options.formats || !slices.Contains(formats, i.ResponseFormat)):
+		err = fmt.Errorf("response format %q is not supported", i.ResponseFormat)
+	case output && !options.gptImage:
+		err = fmt.Errorf("background, output format, compression and moderation are not supported")
+	default:
+		err = i.validateOutput()
+	}
+
+	if err != nil {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model %q: %w", model, err))
+	}
+
+	return nil
+}
+
+// validateOutput checks gpt-image-1 output options.
+func (i *ImageRequest) validateOutput() error {
+	backgrounds := []ImageBackground{ImageBackgroundTransparent, ImageBackgroundOpaque, ImageBackgroundAuto}
+	formats := []ImageOutputFormat{ImageOutputPNG, ImageOutputJPEG, ImageOutputWEBP}
+	moderations := []ImageModeration{ImageModerationLow, ImageModerationAuto}
+
+	switch {
+	case i.Background != "" && !slices.Contains(backgrounds, i.Background):
+		return fmt.Errorf("background %q is not supported", i.Background)
+	case i.OutputFormat != "" && !slices.Contains(formats, i.OutputFormat):
+		return fmt.Errorf("output format %q is not supported", i.OutputFormat)
+	case i.Moderation != "" && !slices.Contains(moderations, i.Moderation):
+		return fmt.Errorf("moderation %q is not supported", i.Moderation)
+	case i.Background == ImageBackgroundTransparent && i.OutputFormat == ImageOutputJPEG:
+		return fmt.Errorf("transparent background requires png or webp output format")
+	case i.OutputCompression != nil && (i.OutputFormat != ImageOutputJPEG && i.OutputFormat != ImageOutputWEBP):
+		return fmt.Errorf("output compression requires jpeg or webp output format")
+	case i.OutputCompression != nil && *i.OutputCompression > 100:
+		return fmt.Errorf("output compression must not be greater than 100")
+	}
+
+	return nil
 }
 
 func (i *ImageRequest) marshal() (io.Reader, error) {
 	if i.Model != "" {
-		if _, ok := imageModels[i.Model]; !ok {
+		if _, known := LookupModel(i.Model); known && !i.Model.Has(CapabilityImage) {
 			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model %q is not allowed for image requests", i.Model))
 		}
 	}
@@ -40,6 +219,10 @@ func (i *ImageRequest) marshal() (io.Reader, error) {
 		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("prompt must not be empty"))
 	}
 
+	if err := i.validateOptions(); err != nil {
+		return nil, err
+	}
+
 	data, err := json.Marshal(i)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal image request: %w", err)
@@ -48,30 +231,50 @@ func (i *ImageRequest) marshal() (io.Reader, error) {
 	return bytes.NewReader(data), nil
 }
 
-func (i *ImageRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
-	body, err := i.marshal()
-	if err != nil {
-		return nil, err
+// variant returns the request model, size and quality with the API default values.
+func (i *ImageRequest) variant() (Model, ImageSize, ImageQuality) {
+	model, size, quality := i.Model, i.Size, i.Quality
+
+	if model == "" {
+		model = ModelDalle2
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.URL, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create image request: %w", err)
+	isGPTImage := imageModels[model].gptImage
+
+	switch {
+	case size != "":
+	case isGPTImage:
+		size = ImageSizeAuto
+	default:
+		size = ImageSize1024
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", auth.Bearer))
+	switch {
+	case quality != "":
+	case isGPTImage:
+		quality = ImageQualityAuto
+	default:
+		quality = ImageQualityStandard
+	}
+
+	return model, size, quality
+}
 
-	if auth.Organization != "" {
-		req.Header.Set("OpenAI-Organization", auth.Organization)
+func (i *ImageRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	body, err := i.marshal()
+	if err != nil {
+		return nil, err
 	}
 
-	return req, nil
+	return newRequest(ctx, auth, i.Model, body, "application/json")
 }
 
-// ImageData stores image URL.
+// ImageData stores image URL or base64 encoded image if b64_json response format is used.
+// RevisedPrompt is returned by dall-e-3 if the prompt was changed.
 type ImageData struct {
-	URL string `json:"url"`
+	URL           string `json:"url"`
+	B64JSON       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
 }
 
 // ImageResponse is a struct of image response.
@@ -79,6 +282,9 @@ type ImageResponse struct {
 	Created   int64       `json:"created"`
 	Data      []ImageData `json:"data"`
 	CreatedTs time.Time   `json:"-"`
+	model     Model
+	size      ImageSize
+	quality   ImageQuality
 }
 
 func (ir *ImageResponse) build(body io.Reader) error {
@@ -94,14 +300,21 @@ func (ir *ImageResponse) build(body io.Reader) error {
 	return nil
 }
 
-// String returns a string representation of the image response.
+// String returns a string representation of the image response, base64 images are shown by size.
 func (ir *ImageResponse) String() string {
 	const sep = "\n"
 	var buf bytes.Buffer
 
 	for i, d := range ir.Data {
 		buf.WriteString(fmt.Sprintf("%d. ", i+1))
-		buf.WriteString(d.URL)
+
+		if d.URL != "" || d.B64JSON == "" {
+			buf.WriteString(d.URL)
+		} else {
+			size := base64.StdEncoding.DecodedLen(len(d.B64JSON)) - (len(d.B64JSON) - len(strings.TrimRight(d.B64JSON, "=")))
+			buf.WriteString(fmt.Sprintf("base64 image, %d bytes", size))
+		}
+
 		buf.WriteString(sep)
 	}
 
@@ -110,19 +323,5 @@ func (ir *ImageResponse) String() string {
 
 // Image sends request to the image API.
 func Image(ctx context.Context, client *http.Client, i *ImageRequest, p Params) (*ImageResponse, error) {
-	body, err := commonRequest(ctx, client, i, p)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		_ = body.Close()
-	}()
-
-	response := &ImageResponse{}
-	if err = response.build(body); err != nil {
-		return nil, err
-	}
-
-	return response, nil
+	return newParamsClient(client, p).Image(ctx, i)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -220,3 +221,154 @@ func TestImageFailedData(t *testing.T) {
 		t.Fatalf("expected %q, got %q", expectedSuffix, e)
 	}
 }
+
+func TestImageRequestOptions(t *testing.T) {
+	compression := uint(80)
+	invalidCompression := uint(101)
+
+	testCases := []struct {
+		name     string
+		request  ImageRequest
+		expected string
+		err      string
+	}{
+		{
+			name: "dall-e-3",
+			request: ImageRequest{
+				Model:          ModelDalle3,
+				Prompt:         "cat",
+				Size:           ImageSize1792x1024,
+				Quality:        ImageQualityHD,
+				Style:          ImageStyleNatural,
+				ResponseFormat: ImageResponseB64JSON,
+			},
+			expected: `{"model":"dall-e-3","prompt":"cat","size":"1792x1024","quality":"hd",` +
+				`"style":"natural","response_format":"b64_json"}`,
+		},
+		{
+			name: "gpt-image-1",
+			request: ImageRequest{
+				Model:             ModelGPTImage1,
+				Prompt:            "cat",
+				N:                 4,
+				Size:              ImageSize1024x1536,
+				Quality:           ImageQualityMedium,
+				Background:        ImageBackgroundOpaque,
+				OutputFormat:      ImageOutputWEBP,
+				OutputCompression: &compression,
+				Moderation:        ImageModerationLow,
+			},
+			expected: `{"model":"gpt-image-1","prompt":"cat","n":4,"size":"1024x1536","quality":"medium",` +
+				`"background":"opaque","output_format":"webp","output_compression":80,"moderation":"low"}`,
+		},
+		{
+			name:     "unknown model",
+			request:  ImageRequest{Model: "custom-image", Prompt: "cat", Size: "640x480", Style: "retro"},
+			expected: `{"model":"custom-image","prompt":"cat","size":"640x480","style":"retro"}`,
+		},
+		{
+			name:    "default model size",
+			request: ImageRequest{Prompt: "cat", Size: ImageSize1792x1024},
+			err:     `size "1792x1024" is not supported`,
+		},
+		{
+			name:    "dall-e-3 images number",
+			request: ImageRequest{Model: ModelDalle3, Prompt: "cat", N: 2},
+			err:     "number of images must not be greater than 1",
+		},
+		{
+			name:    "dall-e-2 quality",
+			request: ImageRequest{Model: ModelDalle2, Prompt: "cat", Quality: ImageQualityHD},
+			err:     `quality "hd" is not supported`,
+		},
+		{
+			name:    "dall-e-2 style",
+			request: ImageRequest{Model: ModelDalle2, Prompt: "cat", Style: ImageStyleVivid},
+			err:     `style "vivid" is not supported`,
+		},
+		{
+			name:    "dall-e-3 style",
+			request: ImageRequest{Model: ModelDalle3, Prompt: "cat", Style: "retro"},
+			err:     `style "retro" is not supported`,
+		},
+		{
+			name:    "dall-e-3 background",
+			request: ImageRequest{Model: ModelDalle3, Prompt: "cat", Background: ImageBackgroundTransparent},
+			err:     "background, output format, compression and moderation are not supported",
+		},
+		{
+			name:    "gpt-image-1 response format",
+			request: ImageRequest{Model: ModelGPTImage1, Prompt: "cat", ResponseFormat: ImageResponseURL},
+			err:     `response format "url" is not supported`,
+		},
+		{
+			name: "transparent jpeg",
+			request: ImageRequest{
+				Model:        ModelGPTImage1,
+				Prompt:       "cat",
+				Background:   ImageBackgroundTransparent,
+				OutputFormat: ImageOutputJPEG,
+			},
+			err: "transparent background requires png or webp output format",
+		},
+		{
+			name:    "png compression",
+			request: ImageRequest{Model: ModelGPTImage1, Prompt: "cat", OutputCompression: &compression},
+			err:     "output compression requires jpeg or webp output format",
+		},
+		{
+			name: "compression",
+			request: ImageRequest{
+				Model:             ModelGPTImage1,
+				Prompt:            "cat",
+				OutputFormat:      ImageOutputJPEG,
+				OutputCompression: &invalidCompression,
+			},
+			err: "output compression must not be greater than 100",
+		},
+		{
+			name:    "moderation",
+			request: ImageRequest{Model: ModelGPTImage1, Prompt: "cat", Moderation: "high"},
+			err:     `moderation "high" is not supported`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := tc.request.marshal()
+			if tc.err != "" {
+				if !errors.Is(err, ErrRequiredParam) || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected %q, got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := string(data); s != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestImageRevisedPrompt(t *testing.T) {
+	body := `{"created":1677652288,"data":[{"url":"https://127.0.0.1/test","revised_prompt":"a red cat"}]}`
+
+	response := &ImageResponse{}
+	if err := response.build(strings.NewReader(body)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p := response.Data[0].RevisedPrompt; p != "a red cat" {
+		t.Errorf("unexpected revised prompt %q", p)
+	}
+}
@@ -0,0 +1,175 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder
+	_ "image/jpeg" // register JPEG decoder
+	_ "image/png"  // register PNG decoder
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultImageMaxSize is the maximum size of downloaded images if the limit is not set.
+const DefaultImageMaxSize = 64 << 20
+
+// imageExtensions is a map of image content types and file extensions.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// Bytes returns the decoded base64 image.
+// Use Download to load images of URL response format.
+func (d *ImageData) Bytes() ([]byte, error) {
+	if d.B64JSON == "" {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("image data is empty"))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(d.B64JSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return data, nil
+}
+
+// ContentType returns the detected image content type, for example, "image/png".
+func (d *ImageData) ContentType() (string, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(data), nil
+}
+
+// Image decodes the image, it returns the format name like image.Decode.
+// PNG, JPEG and GIF formats are supported, other ones like WebP return image.ErrFormat,
+// such images can be saved or written as is.
+func (d *ImageData) Image() (image.Image, string, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if contentType := http.DetectContentType(data); contentType == "image/webp" {
+		return nil, "", errors.Join(image.ErrFormat, fmt.Errorf("%s image decoding is not supported", contentType))
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return img, format, nil
+}
+
+// WriteTo writes the decoded image to w, it implements the io.WriterTo interface.
+func (d *ImageData) WriteTo(w io.Writer) (int64, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Save writes the decoded image to the file, the path extension is replaced by the image format one.
+// The file is readable only by its owner. It returns the path of the written file.
+func (d *ImageData) Save(path string) (string, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(data)
+
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("unknown image content type %q", contentType)
+	}
+
+	path = strings.TrimSuffix(path, filepath.Ext(path)) + ext
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to save image: %w", err)
+	}
+
+	return path, nil
+}
+
+// Download loads the image from URL and stores it as base64 data, so both response formats
+// can be handled the same way. It does nothing if the image data is already set.
+// If maxSize is not positive, DefaultImageMaxSize is used. Nil client means http.DefaultClient.
+func (d *ImageData) Download(ctx context.Context, client *http.Client, maxSize int64) error {
+	if d.B64JSON != "" {
+		return nil
+	}
+
+	if d.URL == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("image URL is empty"))
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if maxSize <= 0 {
+		maxSize = DefaultImageMaxSize
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download image: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Join(ErrResponse, fmt.Errorf("failed to download image, status code %d", resp.StatusCode))
+	}
+
+	if resp.ContentLength > maxSize {
+		return errors.Join(ErrResponse, fmt.Errorf("image size %d is greater than %d", resp.ContentLength, maxSize))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return errors.Join(ErrResponse, fmt.Errorf("image size is greater than %d", maxSize))
+	}
+
+	d.B64JSON = base64.StdEncoding.EncodeToString(data)
+	return nil
+}
+
+// Download loads all URL images of the response, see ImageData.Download.
+func (ir *ImageResponse) Download(ctx context.Context, client *http.Client, maxSize int64) error {
+	for i := range ir.Data {
+		if err := ir.Data[i].Download(ctx, client, maxSize); err != nil {
+			return fmt.Errorf("image %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
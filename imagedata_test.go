@@ -0,0 +1,161 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestImageData(t *testing.T) {
+	data := testPNG(t, 2, 3)
+	d := &ImageData{B64JSON: base64.StdEncoding.EncodeToString(data)}
+
+	img, format, err := d.Image()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if b := img.Bounds(); format != "png" || b.Dx() != 2 || b.Dy() != 3 {
+		t.Errorf("unexpected image %s %v", format, b)
+	}
+
+	if contentType, err := d.ContentType(); err != nil || contentType != "image/png" {
+		t.Errorf("unexpected content type %q, %v", contentType, err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := d.WriteTo(&buf); err != nil || n != int64(len(data)) || !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("unexpected write %d, %v", n, err)
+	}
+
+	path, err := d.Save(filepath.Join(t.TempDir(), "cat.jpg"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if filepath.Base(path) != "cat.png" {
+		t.Errorf("unexpected path %q", path)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(saved, data) {
+		t.Errorf("unexpected saved image: %v", err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("unexpected saved image mode: %v", err)
+	}
+
+	// WebP is returned by gpt-image-1, but there is no standard decoder
+	webp := &ImageData{B64JSON: base64.StdEncoding.EncodeToString([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "))}
+	if _, _, err = webp.Image(); !errors.Is(err, image.ErrFormat) {
+		t.Errorf("expected %v, got %v", image.ErrFormat, err)
+	}
+
+	if path, err = webp.Save(filepath.Join(t.TempDir(), "cat")); err != nil || filepath.Ext(path) != ".webp" {
+		t.Errorf("unexpected webp path %q: %v", path, err)
+	}
+
+	if _, err = (&ImageData{URL: "https://127.0.0.1/test"}).Bytes(); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+
+	if _, _, err = (&ImageData{B64JSON: "!"}).Image(); err == nil {
+		t.Error("expected error")
+	}
+
+	text := &ImageData{B64JSON: base64.StdEncoding.EncodeToString([]byte("text"))}
+	if _, err = text.Save(filepath.Join(t.TempDir(), "text")); err == nil {
+		t.Error("expected error")
+	}
+
+	response := ImageResponse{Data: []ImageData{*d, {URL: "https://127.0.0.1/test"}}}
+	expected := fmt.Sprintf("1. base64 image, %d bytes\n2. https://127.0.0.1/test\n", len(data))
+	if s := response.String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestImageDataDownload(t *testing.T) {
+	data := testPNG(t, 2, 3)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+		case "/chunked.png":
+			w.Header().Set("Transfer-Encoding", "chunked")
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		if _, err := w.Write(data); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	response := &ImageResponse{Data: []ImageData{{URL: s.URL + "/image.png"}, {B64JSON: "AAAA"}}}
+
+	if err := response.Download(ctx, s.Client(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if b, err := response.Data[0].Bytes(); err != nil || !bytes.Equal(b, data) {
+		t.Errorf("unexpected image: %v", err)
+	}
+
+	if response.Data[1].B64JSON != "AAAA" {
+		t.Error("base64 image must not be changed")
+	}
+
+	testCases := []struct {
+		name    string
+		url     string
+		maxSize int64
+		err     error
+	}{
+		{name: "not found", url: s.URL + "/unknown.png", err: ErrResponse},
+		{name: "content length", url: s.URL + "/image.png", maxSize: 10, err: ErrResponse},
+		{name: "chunked", url: s.URL + "/chunked.png", maxSize: 10, err: ErrResponse},
+		{name: "empty", err: ErrRequiredParam},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			d := &ImageData{URL: tc.url}
+			if err := d.Download(ctx, s.Client(), tc.maxSize); !errors.Is(err, tc.err) {
+				t.Errorf("expected %v, got %v", tc.err, err)
+			}
+
+			if d.B64JSON != "" {
+				t.Error("unexpected image data")
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// Maximum sizes of uploaded images.
+const (
+	dalle2UploadMaxSize   = 4 << 20
+	gptImageUploadMaxSize = 50 << 20
+	gptImageMaxImages     = 16
+)
+
+// ImageFile is an uploaded image file, Name is a file name of the form.
+// The content type is detected by the data, dall-e-2 images and masks must be PNG files.
+// Reader is rewound to the start before sending if it implements io.Seeker,
+// otherwise the request is not retried, because its data can not be read again.
+type ImageFile struct {
+	Name   string
+	Reader io.Reader
+}
+
+// imageInfo is a detected format of the image file, sizes are known only for PNG images.
+type imageInfo struct {
+	contentType string
+	width       uint32
+	height      uint32
+}
+
+// upload checks the image file and returns the form file.
+// Content types are not checked if they are empty.
+func (f *ImageFile) upload(field string, maxSize int64, contentTypes ...string) (formFile, imageInfo, error) {
+	var info imageInfo
+
+	if f.Reader == nil {
+		return formFile{}, info, errors.Join(ErrRequiredParam, fmt.Errorf("%s reader must not be nil", field))
+	}
+
+	if err := rewind(f.Reader); err != nil {
+		return formFile{}, info, err
+	}
+
+	if size, ok := readerSize(f.Reader); ok && maxSize > 0 && size > maxSize {
+		return formFile{}, info, errors.Join(
+			ErrRequiredParam, fmt.Errorf("%s size %d is larger than %d bytes", field, size, maxSize),
+		)
+	}
+
+	reader, head, err := sniff(f.Reader)
+	if err != nil {
+		return formFile{}, info, err
+	}
+
+	info.contentType = http.DetectContentType(head)
+	if len(contentTypes) > 0 && !slices.Contains(contentTypes, info.contentType) {
+		return formFile{}, info, errors.Join(
+			ErrRequiredParam, fmt.Errorf("%s content type %q is not supported", field, info.contentType),
+		)
+	}
+
+	// PNG signature is followed by IHDR chunk with width and height
+	if info.contentType == "image/png" && len(head) >= 24 && string(head[12:16]) == "IHDR" {
+		info.width = binary.BigEndian.Uint32(head[16:20])
+		info.height = binary.BigEndian.Uint32(head[20:24])
+	}
+
+	name := f.Name
+	if name == "" {
+		name = field + imageExtensions[info.contentType]
+	}
+
+	file := formFile{field: field, name: name, contentType: info.contentType, reader: reader, maxSize: maxSize}
+	return file, info, nil
+}
+
+// uploadDalle2 checks that the image file is a square PNG image for dall-e-2.
+func (f *ImageFile) uploadDalle2(field string) (formFile, imageInfo, error) {
+	file, info, err := f.upload(field, dalle2UploadMaxSize, "image/png")
+	if err != nil {
+		return file, info, err
+	}
+
+	if info.width != info.height {
+		return file, info, errors.Join(
+			ErrRequiredParam, fmt.Errorf("%s must be square, but it is %dx%d", field, info.width, info.height),
+		)
+	}
+
+	return file, info, nil
+}
+
+// imageFormFields returns the common form fields of image requests.
+func imageFormFields(model Model, n uint, size ImageSize, format ImageResponseFormat, user string) []formField {
+	var fields []formField
+
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, formField{name: name, value: value})
+		}
+	}
+
+	add("model", string(model))
+	if n > 0 {
+		add("n", strconv.FormatUint(uint64(n), 10))
+	}
+	add("size", string(size))
+	add("response_format", string(format))
+	add("user", user)
+
+	return fields
+}
+
+// ImageEditRequest is a request of image edits API.
+// dall-e-2 edits one square PNG image, gpt-image-1 edits up to 16 PNG, JPEG or WEBP images.
+// Transparent areas of the optional PNG mask indicate where the first image should be edited.
+type ImageEditRequest struct {
+	Model             Model
+	Images            []ImageFile
+	Mask              *ImageFile
+	Prompt            string
+	N                 uint
+	Size              ImageSize
+	Quality           ImageQuality
+	ResponseFormat    ImageResponseFormat
+	Background        ImageBackground
+	OutputFormat      ImageOutputFormat
+	OutputCompression *uint
+	User              string
+}
+
+// options returns the generation request with the same options for validation and pricing.
+func (r *ImageEditRequest) options() *ImageRequest {
+	return &ImageRequest{
+		Model:             r.Model,
+		Prompt:            r.Prompt,
+		N:                 r.N,
+		Size:              r.Size,
+		Quality:           r.Quality,
+		ResponseFormat:    r.ResponseFormat,
+		Background:        r.Background,
+		OutputFormat:      r.OutputFormat,
+		OutputCompression: r.OutputCompression,
+	}
+}
+
+// files checks the images and the mask and returns the form files.
+func (r *ImageEditRequest) files() ([]formFile, error) {
+	var (
+		files []formFile
+		first imageInfo
+	)
+
+	model := r.Model
+	if model == "" {
+		model = ModelDalle2
+	}
+
+	if len(r.Images) == 0 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("images must not be empty"))
+	}
+
+	field := "image"
+	switch model {
+	case ModelDalle2:
+		if len(r.Images) > 1 {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model %q edits only one image", model))
+		}
+	case ModelGPTImage1:
+		if len(r.Images) > gptImageMaxImages {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model %q edits up to %d images", model, gptImageMaxImages))
+		}
+
+		if len(r.Images) > 1 {
+			field = "image[]"
+		}
+	default:
+		if _, known := imageModels[model]; known {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("model %q does not support image edits", model))
+		}
+	}
+
+	for i := range r.Images {
+		var (
+			file formFile
+			info imageInfo
+			err  error
+		)
+
+		switch model {
+		case ModelDalle2:
+			file, info, err = r.Images[i].uploadDalle2(field)
+		case ModelGPTImage1:
+			file, info, err = r.Images[i].upload(field, gptImageUploadMaxSize, "image/png", "image/jpeg", "image/webp")
+		default:
+			file, info, err = r.Images[i].upload(field, 0)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			first = info
+		}
+
+		files = append(files, file)
+	}
+
+	if r.Mask == nil {
+		return files, nil
+	}
+
+	maxSize := int64(dalle2UploadMaxSize)
+	if model != ModelDalle2 {
+		maxSize = gptImageUploadMaxSize
+	}
+
+	mask, info, err := r.Mask.upload("mask", maxSize, "image/png")
+	if err != nil {
+		return nil, err
+	}
+
+	if first.width > 0 && (info.width != first.width || info.height != first.height) {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("mask size %dx%d differs from image size %dx%d",
+			info.width, info.height, first.width, first.height,
+		))
+	}
+
+	return append(files, mask), nil
+}
+
+func (r *ImageEditRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	if r.Prompt == "" {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("prompt must not be empty"))
+	}
+
+	if err := r.options().validateOptions(); err != nil {
+		return nil, err
+	}
+
+	files, err := r.files()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := imageFormFields(r.Model, r.N, r.Size, r.ResponseFormat, r.User)
+	fields = append(fields, formField{name: "prompt", value: r.Prompt})
+
+	for _, field := range []formField{
+		{name: "quality", value: string(r.Quality)},
+		{name: "background", value: string(r.Background)},
+		{name: "output_format", value: string(r.OutputFormat)},
+	} {
+		if field.value != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	if r.OutputCompression != nil {
+		fields = append(fields, formField{
+			name:  "output_compression",
+			value: strconv.FormatUint(uint64(*r.OutputCompression), 10),
+		})
+	}
+
+	return newMultipartRequest(ctx, auth, r.Model, fields, files)
+}
+
+// replayable returns true if all files can be read again.
+func (r *ImageEditRequest) replayable() bool {
+	if r.Mask != nil && !isSeeker(r.Mask.Reader) {
+		return false
+	}
+
+	for i := range r.Images {
+		if !isSeeker(r.Images[i].Reader) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ImageVariationRequest is a request of image variations API, only dall-e-2 model is supported.
+type ImageVariationRequest struct {
+	Model          Model
+	Image          ImageFile
+	N              uint
+	Size           ImageSize
+	ResponseFormat ImageResponseFormat
+	User           string
+}
+
+// options returns the generation request with the same options for validation and pricing.
+func (r *ImageVariationRequest) options() *ImageRequest {
+	return &ImageRequest{Model: r.Model, N: r.N, Size: r.Size, ResponseFormat: r.ResponseFormat}
+}
+
+func (r *ImageVariationRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	var (
+		file formFile
+		err  error
+	)
+
+	switch _, known := imageModels[r.Model]; {
+	case r.Model == "" || r.Model == ModelDalle2:
+		file, _, err = r.Image.uploadDalle2("image")
+	case known:
+		err = errors.Join(ErrRequiredParam, fmt.Errorf("model %q does not support image variations", r.Model))
+	default:
+		file, _, err = r.Image.upload("image", 0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.options().validateOptions(); err != nil {
+		return nil, err
+	}
+
+	fields := imageFormFields(r.Model, r.N, r.Size, r.ResponseFormat, r.User)
+	return newMultipartRequest(ctx, auth, r.Model, fields, []formFile{file})
+}
+
+// replayable returns true if the image can be read again.
+func (r *ImageVariationRequest) replayable() bool {
+	return isSeeker(r.Image.Reader)
+}
+
+// ImageEdit sends request to the image edits API.
+func ImageEdit(ctx context.Context, client *http.Client, r *ImageEditRequest, p Params) (*ImageResponse, error) {
+	return newParamsClient(client, p).ImageEdit(ctx, r)
+}
+
+// ImageVariation sends request to the image variations API.
+func ImageVariation(
+	ctx context.Context, client *http.Client, r *ImageVariationRequest, p Params,
+) (*ImageResponse, error) {
+	return newParamsClient(client, p).ImageVariation(ctx, r)
+}
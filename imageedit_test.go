@@ -0,0 +1,268 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// imageEditServer checks multipart forms and fails the first attempts if failures is positive.
+func imageEditServer(
+	t *testing.T, attempts *atomic.Int32, failures int32, check func(r *http.Request),
+) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+
+		if attempts.Add(1) <= failures {
+			http.Error(w, `{"error":{"message":"failed","type":"server_error"}}`, http.StatusInternalServerError)
+			return
+		}
+
+		check(r)
+
+		if _, err := fmt.Fprint(w, `{"created":1677652288,"data":[{"url":"https://127.0.0.1/test"}]}`); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestImageEdit(t *testing.T) {
+	var (
+		attempts atomic.Int32
+		image    = testPNG(t, 4, 4)
+		mask     = testPNG(t, 4, 4)
+	)
+
+	s := imageEditServer(t, &attempts, 1, func(r *http.Request) {
+		if r.URL.Path != imageEditsPath {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		for key, expected := range map[string]string{"prompt": "add a hat", "n": "2", "size": "256x256"} {
+			if value := r.FormValue(key); value != expected {
+				t.Errorf("unexpected %s value %q", key, value)
+			}
+		}
+
+		for field, expected := range map[string][]byte{"image": image, "mask": mask} {
+			f, header, err := r.FormFile(field)
+			if err != nil {
+				t.Fatalf("failed %s file: %v", field, err)
+			}
+
+			if ct := header.Header.Get("Content-Type"); ct != "image/png" {
+				t.Errorf("unexpected content type %q", ct)
+			}
+
+			if data, _ := io.ReadAll(f); !bytes.Equal(data, expected) {
+				t.Errorf("unexpected %s data", field)
+			}
+		}
+	})
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()), WithRetry(&RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	request := &ImageEditRequest{
+		Images: []ImageFile{{Name: "cat.png", Reader: bytes.NewReader(image)}},
+		Mask:   &ImageFile{Reader: bytes.NewReader(mask)},
+		Prompt: "add a hat",
+		N:      2,
+		Size:   ImageSize256,
+	}
+
+	response, err := client.ImageEdit(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("unexpected attempts %d", n)
+	}
+
+	if cost, ok := response.Cost(); !ok || !equalCost(cost, 0.016) {
+		t.Errorf("unexpected cost %v", cost)
+	}
+
+	// not seekable reader is not retried
+	attempts.Store(0)
+	request.Images[0].Reader = io.MultiReader(bytes.NewReader(image))
+	request.Mask = nil
+
+	if _, err = client.ImageEdit(context.Background(), request); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("unexpected attempts %d", n)
+	}
+}
+
+func TestImageEditGPTImage(t *testing.T) {
+	var attempts atomic.Int32
+	s := imageEditServer(t, &attempts, 0, func(r *http.Request) {
+		if files := r.MultipartForm.File["image[]"]; len(files) != 2 || files[1].Filename != "image[].png" {
+			t.Errorf("unexpected files %v", files)
+		}
+
+		if value := r.FormValue("quality"); value != string(ImageQualityLow) {
+			t.Errorf("unexpected quality %q", value)
+		}
+	})
+	defer s.Close()
+
+	request := &ImageEditRequest{
+		Model: ModelGPTImage1,
+		Images: []ImageFile{
+			{Name: "a.png", Reader: bytes.NewReader(testPNG(t, 2, 3))},
+			{Reader: bytes.NewReader(testPNG(t, 3, 2))},
+		},
+		Prompt:  "merge",
+		Quality: ImageQualityLow,
+	}
+
+	if _, err := ImageEdit(context.Background(), s.Client(), request, Params{URL: s.URL}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestImageEditFailed(t *testing.T) {
+	var (
+		square    = testPNG(t, 4, 4)
+		rectangle = testPNG(t, 2, 3)
+	)
+
+	testCases := []struct {
+		name    string
+		request ImageEditRequest
+		err     string
+	}{
+		{
+			name:    "prompt",
+			request: ImageEditRequest{Images: []ImageFile{{Reader: bytes.NewReader(square)}}},
+			err:     "prompt must not be empty",
+		},
+		{
+			name:    "images",
+			request: ImageEditRequest{Prompt: "edit"},
+			err:     "images must not be empty",
+		},
+		{
+			name: "several images",
+			request: ImageEditRequest{
+				Prompt: "edit",
+				Images: []ImageFile{{Reader: bytes.NewReader(square)}, {Reader: bytes.NewReader(square)}},
+			},
+			err: `model "dall-e-2" edits only one image`,
+		},
+		{
+			name: "model",
+			request: ImageEditRequest{
+				Model:  ModelDalle3,
+				Prompt: "edit",
+				Images: []ImageFile{{Reader: bytes.NewReader(square)}},
+			},
+			err: `model "dall-e-3" does not support image edits`,
+		},
+		{
+			name:    "square",
+			request: ImageEditRequest{Prompt: "edit", Images: []ImageFile{{Reader: bytes.NewReader(rectangle)}}},
+			err:     "image must be square, but it is 2x3",
+		},
+		{
+			name:    "png",
+			request: ImageEditRequest{Prompt: "edit", Images: []ImageFile{{Reader: strings.NewReader("GIF89a")}}},
+			err:     `image content type "image/gif" is not supported`,
+		},
+		{
+			name: "size",
+			request: ImageEditRequest{
+				Prompt: "edit",
+				Images: []ImageFile{{Reader: bytes.NewReader(make([]byte, dalle2UploadMaxSize+1))}},
+			},
+			err: "image size 4194305 is larger than 4194304 bytes",
+		},
+		{
+			name: "mask size",
+			request: ImageEditRequest{
+				Model:  ModelGPTImage1,
+				Prompt: "edit",
+				Images: []ImageFile{{Reader: bytes.NewReader(square)}},
+				Mask:   &ImageFile{Reader: bytes.NewReader(rectangle)},
+			},
+			err: "mask size 2x3 differs from image size 4x4",
+		},
+		{
+			name:    "nil reader",
+			request: ImageEditRequest{Model: ModelGPTImage1, Prompt: "edit", Images: []ImageFile{{Name: "a.png"}}},
+			err:     "image reader must not be nil",
+		},
+		{
+			name: "options",
+			request: ImageEditRequest{
+				Prompt:  "edit",
+				Images:  []ImageFile{{Reader: bytes.NewReader(square)}},
+				Quality: ImageQualityHD,
+			},
+			err: `quality "hd" is not supported`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ImageEdit(context.Background(), http.DefaultClient, &tc.request, Params{URL: ":"})
+			if !errors.Is(err, ErrRequiredParam) || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestImageVariation(t *testing.T) {
+	var attempts atomic.Int32
+	s := imageEditServer(t, &attempts, 0, func(r *http.Request) {
+		if r.URL.Path != variationsPath {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		if value := r.FormValue("response_format"); value != string(ImageResponseB64JSON) {
+			t.Errorf("unexpected response format %q", value)
+		}
+	})
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	request := &ImageVariationRequest{
+		Model:          ModelDalle2,
+		Image:          ImageFile{Reader: bytes.NewReader(testPNG(t, 4, 4))},
+		ResponseFormat: ImageResponseB64JSON,
+	}
+
+	if _, err := client.ImageVariation(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request.Model = ModelGPTImage1
+	if _, err := client.ImageVariation(context.Background(), request); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+
+	request.Model, request.N = ModelDalle2, 11
+	if _, err := client.ImageVariation(context.Background(), request); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
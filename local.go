@@ -0,0 +1,50 @@
+package aoapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Default base URLs of local OpenAI compatible servers.
+const (
+	OllamaBaseURL   = "http://localhost:11434/v1"
+	LlamaCppBaseURL = "http://localhost:8080/v1"
+	VLLMBaseURL     = "http://localhost:8000/v1"
+	LMStudioBaseURL = "http://localhost:1234/v1"
+)
+
+// Local is a provider of local OpenAI compatible servers like Ollama, llama.cpp server, vLLM or LM Studio.
+// BaseURL is OllamaBaseURL by default, authorization header is sent only if APIKey is not empty.
+// Options are added to every chat completion request, for example,
+// {"keep_alive": "10m", "options": {"num_ctx": 8192}} for Ollama,
+// request Extra parameters take precedence over them.
+type Local struct {
+	BaseURL string
+	APIKey  string
+	Options map[string]any
+}
+
+// URL returns the server URL of the API path.
+func (l *Local) URL(path string, _ Model) (string, error) {
+	baseURL := l.BaseURL
+	if baseURL == "" {
+		baseURL = OllamaBaseURL
+	}
+
+	return strings.TrimRight(baseURL, "/") + path, nil
+}
+
+// Authorize sets bearer authorization header if the API key is not empty.
+func (l *Local) Authorize(_ context.Context, header http.Header) error {
+	if l.APIKey != "" {
+		header.Set("Authorization", "Bearer "+l.APIKey)
+	}
+
+	return nil
+}
+
+// extra returns the options of chat completion requests.
+func (l *Local) extra() map[string]any {
+	return l.Options
+}
@@ -0,0 +1,163 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLocalURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		local    Local
+		expected string
+	}{
+		{name: "default", expected: "http://localhost:11434/v1/chat/completions"},
+		{name: "custom", local: Local{BaseURL: VLLMBaseURL + "/"}, expected: "http://localhost:8000/v1/chat/completions"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := tc.local.URL(completionsPath, "llama3.2")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if u != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, u)
+			}
+		})
+	}
+}
+
+func TestCompletionRequestExtra(t *testing.T) {
+	local := &Local{Options: map[string]any{"keep_alive": "5m", "options": map[string]any{"num_ctx": 8192}}}
+	testCases := []struct {
+		name     string
+		extra    map[string]any
+		expected string
+		err      bool
+	}{
+		{
+			name: "provider",
+			expected: `{"keep_alive":"5m","messages":[{"role":"user","content":"Hello"}],` +
+				`"model":"llama3.2","options":{"num_ctx":8192}}`,
+		},
+		{
+			name:  "request",
+			extra: map[string]any{"keep_alive": -1, "top_k": 20},
+			expected: `{"keep_alive":-1,"messages":[{"role":"user","content":"Hello"}],` +
+				`"model":"llama3.2","options":{"num_ctx":8192},"top_k":20}`,
+		},
+		{
+			name:  "conflict",
+			extra: map[string]any{"model": "other"},
+			err:   true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := &CompletionRequest{
+				Model:    "llama3.2",
+				Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+				Extra:    tc.extra,
+			}
+
+			req, err := request.build(context.Background(), &Params{Provider: local, path: completionsPath})
+			if tc.err {
+				if !errors.Is(err, ErrRequiredParam) {
+					t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := string(data); s != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestLocalClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth, ok := r.Header["Authorization"]; ok {
+			t.Errorf("unexpected authorization header %q", auth)
+		}
+
+		var response string
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			response = `{"id":"test","object":"chat.completion","created":1677652288,"model":"llama3.2",` +
+				`"choices":[{"index":0,"message":{"content":"Hello","role":"assistant"},"finish_reason":"stop"}],` +
+				`"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`
+		case "/v1/models":
+			if r.Method != http.MethodGet {
+				t.Errorf("unexpected method %s", r.Method)
+			}
+
+			response = `{"object":"list","data":[{"id":"llama3.2","object":"model","created":1686935002,` +
+				`"owned_by":"library"},{"id":"qwen3:8b","object":"model"}]}`
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	client := NewClient(WithProvider(&Local{BaseURL: s.URL + "/v1"}), WithHTTPClient(s.Client()))
+	ctx := context.Background()
+
+	request := &CompletionRequest{Model: "llama3.2", Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	response, err := client.Completion(ctx, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := response.String(); s != "Hello" {
+		t.Errorf("unexpected response %q", s)
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ModelObject{
+		{ID: "llama3.2", Object: "model", Created: 1686935002, OwnedBy: "library"},
+		{ID: "qwen3:8b", Object: "model"},
+	}
+
+	if !reflect.DeepEqual(models, expected) {
+		t.Errorf("expected %v, got %v", expected, models)
+	}
+
+	// OpenAI compatible client without provider and bearer
+	models, err = ListModels(ctx, s.Client(), Params{URL: s.URL + "/v1/models"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Errorf("unexpected models %v", models)
+	}
+}
@@ -0,0 +1,227 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Capability is a set of AI model features.
+type Capability uint
+
+// Model capabilities.
+const (
+	CapabilityChat Capability = 1 << iota
+	CapabilityImage
+	CapabilityReasoning
+	CapabilityVision
+	CapabilityTools
+	CapabilityEmbedding
+	CapabilityVerbosity
+	CapabilityTranscription
+	CapabilitySpeech
+	CapabilityReasoningEffort
+	CapabilityMinimalEffort
+)
+
+// Has returns true if all capabilities of c are set.
+func (c Capability) Has(capabilities Capability) bool {
+	return c&capabilities == capabilities
+}
+
+// ModelInfo is a struct of AI model description.
+// MaxTokens is the maximum number of output tokens, ContextWindow is the total input+output tokens.
+// Zero limits mean that they are unknown and not validated.
+// Encoding is used for local token counting, tokens are estimated if it is empty.
+type ModelInfo struct {
+	Name          Model
+	Capabilities  Capability
+	MaxTokens     uint
+	ContextWindow uint
+	Encoding      TokenEncoding
+}
+
+// modelRegistry is a thread-safe storage of known models.
+type modelRegistry struct {
+	sync.RWMutex
+	models map[Model]ModelInfo
+}
+
+var registry = &modelRegistry{models: make(map[Model]ModelInfo)}
+
+// initialTokenLimits is a copy of TokenLimits to detect its changes after the package initialization.
+var initialTokenLimits = maps.Clone(TokenLimits)
+
+func init() {
+	const (
+		chat      = CapabilityChat | CapabilityTools
+		vision    = chat | CapabilityVision
+		reasoning = CapabilityChat | CapabilityReasoning
+		effort    = CapabilityReasoning | CapabilityReasoningEffort
+		gpt5      = vision | effort | CapabilityMinimalEffort | CapabilityVerbosity
+	)
+
+	defaults := []ModelInfo{
+		{Name: ModelDalle2, Capabilities: CapabilityImage},
+		{Name: ModelDalle3, Capabilities: CapabilityImage},
+		{Name: ModelGPTImage1, Capabilities: CapabilityImage},
+		{Name: ModelGPT35Turbo, Capabilities: chat, ContextWindow: 16_385},
+		{Name: ModelGPT4, Capabilities: chat, ContextWindow: 8192},
+		{Name: ModelGPT4Turbo, Capabilities: vision, ContextWindow: 128_000},
+		{Name: ModelGPT4o, Capabilities: vision, ContextWindow: 128_000},
+		{Name: ModelGPT4oTurbo, Capabilities: vision, ContextWindow: 128_000},
+		{Name: ModelGPT4oMini, Capabilities: vision, ContextWindow: 128_000},
+		{Name: ModelGPT41, Capabilities: vision, ContextWindow: 1_047_576},
+		{Name: ModelGPT41Mini, Capabilities: vision, ContextWindow: 1_047_576},
+		{Name: ModelGPT41Nano, Capabilities: vision, ContextWindow: 1_047_576},
+		{Name: ModelGPT45Preview, Capabilities: vision, ContextWindow: 128_000},
+		{Name: ModelGPT5, Capabilities: gpt5, ContextWindow: 400_000},
+		{Name: ModelGPT5Mini, Capabilities: gpt5, ContextWindow: 400_000},
+		{Name: ModelGPT5Nano, Capabilities: gpt5, ContextWindow: 400_000},
+		{Name: ModelGPT5ChatLatest, Capabilities: CapabilityChat | CapabilityVision, ContextWindow: 400_000},
+		{Name: ModelGPTo1, Capabilities: vision | effort, ContextWindow: 200_000},
+		{Name: ModelGPTo1Mini, Capabilities: reasoning, ContextWindow: 128_000},
+		{Name: ModelGPTo1Preview, Capabilities: reasoning, ContextWindow: 128_000},
+		{Name: ModelGPTo1Pro, Capabilities: vision | effort, ContextWindow: 200_000},
+		{Name: ModelGPTo3Mini, Capabilities: chat | effort, ContextWindow: 200_000},
+		{Name: ModelCodexMiniLatest, Capabilities: vision | effort, ContextWindow: 200_000},
+		{Name: ModelDeepSeekChat, Capabilities: chat, ContextWindow: 65_536},
+		{Name: ModelDeepSeekReasoner, Capabilities: reasoning, ContextWindow: 65_536},
+		{Name: ModelClaudeOpus41, Capabilities: vision | CapabilityReasoning, MaxTokens: 32_000, ContextWindow: 200_000},
+		{Name: ModelClaudeSonnet4, Capabilities: vision | CapabilityReasoning, MaxTokens: 64_000, ContextWindow: 200_000},
+		{Name: ModelClaude35Haiku, Capabilities: vision, MaxTokens: 8192, ContextWindow: 200_000},
+		{Name: ModelGemini25Pro, Capabilities: vision | CapabilityReasoning, MaxTokens: 65_536, ContextWindow: 1_048_576},
+		{Name: ModelGemini25Flash, Capabilities: vision | CapabilityReasoning, MaxTokens: 65_536, ContextWindow: 1_048_576},
+		{Name: ModelTextEmbedding3Small, Capabilities: CapabilityEmbedding, ContextWindow: 8192},
+		{Name: ModelTextEmbedding3Large, Capabilities: CapabilityEmbedding, ContextWindow: 8192},
+		{Name: ModelTextEmbeddingAda002, Capabilities: CapabilityEmbedding, ContextWindow: 8192},
+		{Name: ModelWhisper1, Capabilities: CapabilityTranscription},
+		{Name: ModelGPT4oTranscribe, Capabilities: CapabilityTranscription, MaxTokens: 2000, ContextWindow: 16_000},
+		{Name: ModelGPT4oMiniTranscribe, Capabilities: CapabilityTranscription, MaxTokens: 2000, ContextWindow: 16_000},
+		{Name: ModelTTS1, Capabilities: CapabilitySpeech},
+		{Name: ModelTTS1HD, Capabilities: CapabilitySpeech},
+		{Name: ModelGPT4oMiniTTS, Capabilities: CapabilitySpeech, ContextWindow: 2000},
+	}
+
+	cl100k := []Model{
+		ModelGPT35Turbo, ModelGPT4, ModelGPT4Turbo,
+		ModelTextEmbedding3Small, ModelTextEmbedding3Large, ModelTextEmbeddingAda002,
+	}
+	o200k := []Model{
+		ModelGPT4o, ModelGPT4oTurbo, ModelGPT4oMini, ModelGPT41, ModelGPT41Mini, ModelGPT41Nano, ModelGPT45Preview,
+		ModelGPT5, ModelGPT5Mini, ModelGPT5Nano, ModelGPT5ChatLatest,
+		ModelGPTo1, ModelGPTo1Mini, ModelGPTo1Preview, ModelGPTo1Pro, ModelGPTo3Mini, ModelCodexMiniLatest,
+	}
+
+	for _, info := range defaults {
+		if limit, ok := TokenLimits[info.Name]; ok {
+			info.MaxTokens = limit
+		}
+
+		switch {
+		case slices.Contains(cl100k, info.Name):
+			info.Encoding = EncodingCL100kBase
+		case slices.Contains(o200k, info.Name):
+			info.Encoding = EncodingO200kBase
+		}
+
+		registry.models[info.Name] = info
+	}
+}
+
+// RegisterModel adds or replaces the model description in the registry.
+// It is safe for concurrent use.
+func RegisterModel(info ModelInfo) error {
+	if strings.TrimSpace(string(info.Name)) == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model name must not be empty"))
+	}
+
+	if info.ContextWindow > 0 && info.MaxTokens > info.ContextWindow {
+		return errors.Join(
+			ErrRequiredParam,
+			fmt.Errorf("max tokens %d is greater than context window %d", info.MaxTokens, info.ContextWindow),
+		)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.models[info.Name] = info
+	return nil
+}
+
+// LookupModel returns the model description from the registry.
+// It is safe for concurrent use.
+func LookupModel(m Model) (ModelInfo, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	info, ok := registry.models[m]
+	return info, ok
+}
+
+// Models returns all registered models sorted by name.
+func Models() []ModelInfo {
+	registry.RLock()
+	result := make([]ModelInfo, 0, len(registry.models))
+
+	for _, info := range registry.models {
+		result = append(result, info)
+	}
+	registry.RUnlock()
+
+	slices.SortFunc(result, func(a, b ModelInfo) int {
+		return strings.Compare(string(a.Name), string(b.Name))
+	})
+
+	return result
+}
+
+// Has returns true if the model is known and has all capabilities.
+func (m Model) Has(capabilities Capability) bool {
+	info, ok := LookupModel(m)
+	return ok && info.Capabilities.Has(capabilities)
+}
+
+// maxTokens returns the maximum number of output tokens for a known model.
+// It returns false if the model is unknown or has no limit.
+// Limits of the deprecated TokenLimits are used if they were changed or added at runtime.
+func (m Model) maxTokens() (uint, bool) {
+	if limit, ok := TokenLimits[m]; ok && limit != initialTokenLimits[m] {
+		return limit, limit > 0
+	}
+
+	info, ok := LookupModel(m)
+	return info.MaxTokens, ok && info.MaxTokens > 0
+}
+
+// ModelObject is a model description of the models listing API.
+type ModelObject struct {
+	ID      Model  `json:"id"`
+	Object  string `json:"object,omitempty"`
+	Created int64  `json:"created,omitempty"`
+	OwnedBy string `json:"owned_by,omitempty"`
+}
+
+// modelsResponse is a response of the models listing API.
+type modelsResponse struct {
+	Data []ModelObject `json:"data"`
+}
+
+// modelsRequest is a request of the models listing API.
+type modelsRequest struct{}
+
+// build creates a new GET request without body.
+func (m *modelsRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	return newMethodRequest(ctx, http.MethodGet, auth, "", nil, "")
+}
+
+// ListModels returns models available for the API.
+func ListModels(ctx context.Context, client *http.Client, p Params) ([]ModelObject, error) {
+	return newParamsClient(client, p).ListModels(ctx)
+}
@@ -0,0 +1,145 @@
+package aoapi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCapability_Has(t *testing.T) {
+	c := CapabilityChat | CapabilityTools
+
+	if !c.Has(CapabilityChat) || !c.Has(CapabilityChat|CapabilityTools) {
+		t.Error("expected capabilities")
+	}
+
+	if c.Has(CapabilityVision) || c.Has(CapabilityChat|CapabilityVision) {
+		t.Error("unexpected capabilities")
+	}
+}
+
+func TestRegisterModel(t *testing.T) {
+	const model Model = "test-register-model"
+
+	if _, ok := LookupModel(model); ok {
+		t.Fatalf("model %v must not be registered", model)
+	}
+
+	info := ModelInfo{Name: model, Capabilities: CapabilityChat | CapabilityVision, MaxTokens: 100, ContextWindow: 1000}
+	if err := RegisterModel(info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found, ok := LookupModel(model)
+	if !ok || found != info {
+		t.Fatalf("expected %v, got %v", info, found)
+	}
+
+	if !model.Has(CapabilityVision) || model.Has(CapabilityImage) {
+		t.Errorf("unexpected capabilities: %v", found.Capabilities)
+	}
+
+	var listed bool
+	for _, m := range Models() {
+		if m.Name == model {
+			listed = true
+		}
+	}
+
+	if !listed {
+		t.Errorf("model %v is not listed", model)
+	}
+
+	request := CompletionRequest{Model: model, Messages: []Message{{Role: RoleUser, Content: "Hello"}}, MaxTokens: 101}
+	if _, err := request.marshal(); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+
+	request.Model = "test-unknown-model"
+	if _, err := request.marshal(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRegisterModelFailed(t *testing.T) {
+	testCases := []struct {
+		name string
+		info ModelInfo
+	}{
+		{name: "empty", info: ModelInfo{Name: " "}},
+		{name: "limits", info: ModelInfo{Name: "test-limits", MaxTokens: 10, ContextWindow: 5}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if err := RegisterModel(tc.info); !errors.Is(err, ErrRequiredParam) {
+				t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+			}
+		})
+	}
+}
+
+func TestRegisterModelConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			model := Model(fmt.Sprintf("test-concurrent-%d", i))
+
+			if err := RegisterModel(ModelInfo{Name: model, Capabilities: CapabilityChat}); err != nil {
+				t.Error(err)
+			}
+
+			if !model.Has(CapabilityChat) {
+				t.Errorf("model %v is not registered", model)
+			}
+
+			_ = Models()
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestImageCustomModel(t *testing.T) {
+	request := ImageRequest{Model: "test-image-model", Prompt: "test"}
+	if _, err := request.marshal(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTokenLimitsFallback(t *testing.T) {
+	const custom Model = "test-token-limits"
+
+	TokenLimits[ModelGPT4] = 100
+	TokenLimits[custom] = 50
+
+	t.Cleanup(func() {
+		TokenLimits[ModelGPT4] = initialTokenLimits[ModelGPT4]
+		delete(TokenLimits, custom)
+	})
+
+	testCases := []struct {
+		model    Model
+		expected uint
+		ok       bool
+	}{
+		{model: ModelGPT4, expected: 100, ok: true},
+		{model: custom, expected: 50, ok: true},
+		{model: ModelGPT4o, expected: 4096, ok: true},
+		{model: "test-unknown"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(string(tc.model), func(t *testing.T) {
+			if limit, ok := tc.model.maxTokens(); limit != tc.expected || ok != tc.ok {
+				t.Errorf("expected %d/%v, got %d/%v", tc.expected, tc.ok, limit, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package aoapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// sniffLen is a number of bytes to detect the file content type.
+const sniffLen = 512
+
+// formField is a text field of the multipart form.
+type formField struct {
+	name  string
+	value string
+}
+
+// formFile is a file of the multipart form, its data is streamed from the reader.
+// The size is checked during the streaming if maxSize is positive.
+type formFile struct {
+	field       string
+	name        string
+	contentType string
+	reader      io.Reader
+	maxSize     int64
+}
+
+// newMultipartBody returns a reader of the multipart form and its content type.
+// The form is written by a goroutine, so files are not loaded to memory.
+// The goroutine is stopped when the reader is closed, HTTP client does it for request bodies.
+func newMultipartBody(fields []formField, files []formFile) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(w, fields, files))
+	}()
+
+	return pr, w.FormDataContentType()
+}
+
+// newMultipartRequest creates a new POST HTTP request with the multipart form body.
+// The body is closed if the request is not created, so the form writer goroutine is stopped.
+func newMultipartRequest(
+	ctx context.Context, auth *Params, model Model, fields []formField, files []formFile,
+) (*http.Request, error) {
+	body, contentType := newMultipartBody(fields, files)
+
+	req, err := newRequest(ctx, auth, model, body, contentType)
+	if err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// writeMultipart writes the form fields and files.
+func writeMultipart(w *multipart.Writer, fields []formField, files []formFile) error {
+	for _, field := range fields {
+		if err := w.WriteField(field.name, field.value); err != nil {
+			return fmt.Errorf("failed to write field %q: %w", field.name, err)
+		}
+	}
+
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(file.field), escapeQuotes(file.name),
+		))
+		header.Set("Content-Type", file.contentType)
+
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return fmt.Errorf("failed to create file %q: %w", file.name, err)
+		}
+
+		reader := file.reader
+		if file.maxSize > 0 {
+			reader = io.LimitReader(reader, file.maxSize+1)
+		}
+
+		n, err := io.Copy(part, reader)
+		if err != nil {
+			return fmt.Errorf("failed to write file %q: %w", file.name, err)
+		}
+
+		if file.maxSize > 0 && n > file.maxSize {
+			return errors.Join(ErrRequiredParam, fmt.Errorf("file %q is larger than %d bytes", file.name, file.maxSize))
+		}
+	}
+
+	return w.Close()
+}
+
+// escapeQuotes escapes quotes of the multipart header values like mime/multipart does.
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+// readerSize returns the size of the remaining data if the reader knows it.
+func readerSize(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case interface{ Len() int }: // bytes.Reader, strings.Reader, bytes.Buffer
+		return int64(v.Len()), true
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+
+		return info.Size() - offset, true
+	default:
+		return 0, false
+	}
+}
+
+// rewind seeks the reader to the start if it is a seeker.
+func rewind(r io.Reader) error {
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return nil
+	}
+
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	return nil
+}
+
+// isSeeker returns true if the reader can be read again after rewind.
+func isSeeker(r io.Reader) bool {
+	_, ok := r.(io.Seeker)
+	return ok
+}
+
+// sniff returns a buffered reader of r and the first bytes of the data.
+func sniff(r io.Reader) (*bufio.Reader, []byte, error) {
+	reader := bufio.NewReaderSize(r, sniffLen)
+
+	head, err := reader.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return reader, head, nil
+}
@@ -0,0 +1,141 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewMultipartBody(t *testing.T) {
+	fields := []formField{{name: "model", value: "whisper-1"}}
+	files := []formFile{{field: "file", name: `a "b".txt`, contentType: "text/plain", reader: strings.NewReader("abc")}}
+
+	body, contentType := newMultipartBody(fields, files)
+	defer func() {
+		_ = body.Close()
+	}()
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if value := form.Value["model"]; len(value) != 1 || value[0] != "whisper-1" {
+		t.Errorf("unexpected value %v", value)
+	}
+
+	header := form.File["file"][0]
+	if header.Filename != `a "b".txt` || header.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected file header %v", header.Header)
+	}
+
+	// the file is larger than the limit
+	files[0].reader, files[0].maxSize = strings.NewReader("abcd"), 3
+	body, _ = newMultipartBody(nil, files)
+
+	if _, err = io.ReadAll(body); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
+
+func TestReaderSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte("abcdef"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if _, err = f.Seek(2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		reader io.Reader
+		size   int64
+		ok     bool
+	}{
+		{name: "bytes", reader: bytes.NewReader([]byte("abc")), size: 3, ok: true},
+		{name: "file", reader: f, size: 4, ok: true},
+		{name: "unknown", reader: io.MultiReader(strings.NewReader("abc"))},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if size, ok := readerSize(tc.reader); size != tc.size || ok != tc.ok {
+				t.Errorf("expected %d (%v), got %d (%v)", tc.size, tc.ok, size, ok)
+			}
+		})
+	}
+}
+
+func TestMultipartRequestNotLeaked(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		client = NewClient(WithProvider(&Anthropic{APIKey: "test"})) // only chat completions are supported
+		image  = testPNG(t, 4, 4)
+		before = runtime.NumGoroutine()
+	)
+
+	for range 10 {
+		requests := []func() error{
+			func() error {
+				r := &TranscriptionRequest{Model: ModelWhisper1, File: AudioFile{Name: "a.mp3", Reader: strings.NewReader("ID3")}}
+				_, err := client.Transcription(ctx, r)
+				return err
+			},
+			func() error {
+				r := &TranslationRequest{Model: ModelWhisper1, File: AudioFile{Name: "a.mp3", Reader: strings.NewReader("ID3")}}
+				_, err := client.Translation(ctx, r)
+				return err
+			},
+			func() error {
+				r := &ImageEditRequest{Prompt: "cat", Images: []ImageFile{{Reader: bytes.NewReader(image)}}}
+				_, err := client.ImageEdit(ctx, r)
+				return err
+			},
+			func() error {
+				_, err := client.ImageVariation(ctx, &ImageVariationRequest{Image: ImageFile{Reader: bytes.NewReader(image)}})
+				return err
+			},
+		}
+
+		for _, request := range requests {
+			if err := request(); !errors.Is(err, ErrRequiredParam) {
+				t.Fatalf("expected %v, got %v", ErrRequiredParam, err)
+			}
+		}
+	}
+
+	// form writer goroutines are stopped asynchronously
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines are leaked: %d before, %d after", before, runtime.NumGoroutine())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,228 @@
+package aoapi
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// tokensPerPrice is a number of tokens for Price token prices.
+const tokensPerPrice = 1_000_000
+
+// ImageVariant is a key of generated image prices.
+type ImageVariant struct {
+	Size    ImageSize
+	Quality ImageQuality
+}
+
+// Price is a model price in USD, token prices are per million tokens.
+// If CachedInput or Reasoning prices are zero, Input and Output prices are used for such tokens.
+// Images contains prices of one generated image, AudioMinute is a price of one minute of transcribed audio.
+type Price struct {
+	Model       Model
+	Input       float64
+	CachedInput float64
+	Output      float64
+	Reasoning   float64
+	Images      map[ImageVariant]float64
+	AudioMinute float64
+}
+
+// Cost returns the cost of the tokens usage.
+// Cached and reasoning tokens are parts of the prompt and completion tokens.
+func (p Price) Cost(u Usage) float64 {
+	cached := u.PromptTokensDetails.CachedTokens
+	if cached == 0 {
+		cached = u.PromptCacheHitTokens
+	}
+
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+
+	reasoning := u.CompletionTokensDetails.ReasoningTokens
+	reasoningPrice := p.Reasoning
+	if reasoningPrice == 0 {
+		reasoningPrice = p.Output
+	}
+
+	cost := float64(u.PromptTokens-min(cached, u.PromptTokens))*p.Input +
+		float64(cached)*cachedPrice +
+		float64(u.CompletionTokens-min(reasoning, u.CompletionTokens))*p.Output +
+		float64(reasoning)*reasoningPrice
+
+	return cost / tokensPerPrice
+}
+
+// ImageCost returns the cost of one image and true if its price is known.
+// If the size or quality is auto and there is no price for it, the chosen variant is unknown,
+// so the most expensive price of variants with the other same parameter is returned.
+func (p Price) ImageCost(size ImageSize, quality ImageQuality) (float64, bool) {
+	if cost, ok := p.Images[ImageVariant{Size: size, Quality: quality}]; ok {
+		return cost, true
+	}
+
+	if size != ImageSizeAuto && quality != ImageQualityAuto {
+		return 0, false
+	}
+
+	var (
+		maxCost float64
+		found   bool
+	)
+
+	for variant, cost := range p.Images {
+		if (size == ImageSizeAuto || variant.Size == size) && (quality == ImageQualityAuto || variant.Quality == quality) {
+			maxCost, found = max(maxCost, cost), true
+		}
+	}
+
+	return maxCost, found
+}
+
+// priceRegistry is a thread-safe storage of model prices.
+type priceRegistry struct {
+	sync.RWMutex
+	prices map[Model]Price
+}
+
+var pricing = &priceRegistry{prices: make(map[Model]Price)}
+
+func init() {
+	dalle2 := map[ImageVariant]float64{
+		{Size: ImageSize256, Quality: ImageQualityStandard}:  0.016,
+		{Size: ImageSize512, Quality: ImageQualityStandard}:  0.018,
+		{Size: ImageSize1024, Quality: ImageQualityStandard}: 0.02,
+	}
+	dalle3 := map[ImageVariant]float64{
+		{Size: ImageSize1024, Quality: ImageQualityStandard}:      0.04,
+		{Size: ImageSize1792x1024, Quality: ImageQualityStandard}: 0.08,
+		{Size: ImageSize1024x1792, Quality: ImageQualityStandard}: 0.08,
+		{Size: ImageSize1024, Quality: ImageQualityHD}:            0.08,
+		{Size: ImageSize1792x1024, Quality: ImageQualityHD}:       0.12,
+		{Size: ImageSize1024x1792, Quality: ImageQualityHD}:       0.12,
+	}
+	gptImage1 := map[ImageVariant]float64{
+		{Size: ImageSize1024, Quality: ImageQualityLow}:         0.011,
+		{Size: ImageSize1536x1024, Quality: ImageQualityLow}:    0.016,
+		{Size: ImageSize1024x1536, Quality: ImageQualityLow}:    0.016,
+		{Size: ImageSize1024, Quality: ImageQualityMedium}:      0.042,
+		{Size: ImageSize1536x1024, Quality: ImageQualityMedium}: 0.063,
+		{Size: ImageSize1024x1536, Quality: ImageQualityMedium}: 0.063,
+		{Size: ImageSize1024, Quality: ImageQualityHigh}:        0.167,
+		{Size: ImageSize1536x1024, Quality: ImageQualityHigh}:   0.25,
+		{Size: ImageSize1024x1536, Quality: ImageQualityHigh}:   0.25,
+	}
+
+	defaults := []Price{
+		{Model: ModelDalle2, Images: dalle2},
+		{Model: ModelDalle3, Images: dalle3},
+		{Model: ModelGPTImage1, Images: gptImage1},
+		{Model: ModelGPT35Turbo, Input: 0.5, Output: 1.5},
+		{Model: ModelGPT4, Input: 30, Output: 60},
+		{Model: ModelGPT4Turbo, Input: 10, Output: 30},
+		{Model: ModelGPT4o, Input: 2.5, CachedInput: 1.25, Output: 10},
+		{Model: ModelGPT4oTurbo, Input: 2.5, CachedInput: 1.25, Output: 10},
+		{Model: ModelGPT4oMini, Input: 0.15, CachedInput: 0.075, Output: 0.6},
+		{Model: ModelGPT41, Input: 2, CachedInput: 0.5, Output: 8},
+		{Model: ModelGPT41Mini, Input: 0.4, CachedInput: 0.1, Output: 1.6},
+		{Model: ModelGPT41Nano, Input: 0.1, CachedInput: 0.025, Output: 0.4},
+		{Model: ModelGPT45Preview, Input: 75, CachedInput: 37.5, Output: 150},
+		{Model: ModelGPT5, Input: 1.25, CachedInput: 0.125, Output: 10},
+		{Model: ModelGPT5Mini, Input: 0.25, CachedInput: 0.025, Output: 2},
+		{Model: ModelGPT5Nano, Input: 0.05, CachedInput: 0.005, Output: 0.4},
+		{Model: ModelGPT5ChatLatest, Input: 1.25, CachedInput: 0.125, Output: 10},
+		{Model: ModelGPTo1, Input: 15, CachedInput: 7.5, Output: 60},
+		{Model: ModelGPTo1Mini, Input: 1.1, CachedInput: 0.55, Output: 4.4},
+		{Model: ModelGPTo1Preview, Input: 15, CachedInput: 7.5, Output: 60},
+		{Model: ModelGPTo1Pro, Input: 150, Output: 600},
+		{Model: ModelGPTo3Mini, Input: 1.1, CachedInput: 0.55, Output: 4.4},
+		{Model: ModelCodexMiniLatest, Input: 1.5, CachedInput: 0.375, Output: 6},
+		{Model: ModelDeepSeekChat, Input: 0.28, CachedInput: 0.028, Output: 0.42},
+		{Model: ModelDeepSeekReasoner, Input: 0.28, CachedInput: 0.028, Output: 0.42},
+		{Model: ModelClaudeOpus41, Input: 15, CachedInput: 1.5, Output: 75},
+		{Model: ModelClaudeSonnet4, Input: 3, CachedInput: 0.3, Output: 15},
+		{Model: ModelClaude35Haiku, Input: 0.8, CachedInput: 0.08, Output: 4},
+		{Model: ModelGemini25Pro, Input: 1.25, CachedInput: 0.31, Output: 10},
+		{Model: ModelGemini25Flash, Input: 0.3, CachedInput: 0.075, Output: 2.5},
+		{Model: ModelTextEmbedding3Small, Input: 0.02},
+		{Model: ModelTextEmbedding3Large, Input: 0.13},
+		{Model: ModelTextEmbeddingAda002, Input: 0.1},
+		{Model: ModelWhisper1, AudioMinute: 0.006},
+		{Model: ModelGPT4oTranscribe, Input: 6, Output: 10},
+		{Model: ModelGPT4oMiniTranscribe, Input: 3, Output: 5},
+		{Model: ModelTTS1, Input: 15},   // per million input characters
+		{Model: ModelTTS1HD, Input: 30}, // per million input characters
+		{Model: ModelGPT4oMiniTTS, Input: 0.6, Output: 12},
+	}
+
+	for _, price := range defaults {
+		pricing.prices[price.Model] = price
+	}
+}
+
+// RegisterPrice adds or replaces the model price in the registry.
+// It is safe for concurrent use.
+func RegisterPrice(price Price) error {
+	if strings.TrimSpace(string(price.Model)) == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model name must not be empty"))
+	}
+
+	if price.Input < 0 || price.CachedInput < 0 || price.Output < 0 || price.Reasoning < 0 || price.AudioMinute < 0 {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("price of model %q must not be negative", price.Model))
+	}
+
+	for variant, cost := range price.Images {
+		if cost < 0 {
+			return errors.Join(ErrRequiredParam, fmt.Errorf("image price %v must not be negative", variant))
+		}
+	}
+
+	pricing.Lock()
+	defer pricing.Unlock()
+
+	price.Images = maps.Clone(price.Images)
+	pricing.prices[price.Model] = price
+
+	return nil
+}
+
+// LookupPrice returns a copy of the model price from the registry.
+// It is safe for concurrent use.
+func LookupPrice(m Model) (Price, bool) {
+	pricing.RLock()
+	defer pricing.RUnlock()
+
+	price, ok := pricing.prices[m]
+	price.Images = maps.Clone(price.Images)
+
+	return price, ok
+}
+
+// Cost returns the response cost in USD and true if the model price is known.
+func (r *CompletionResponse) Cost() (float64, bool) {
+	price, ok := LookupPrice(r.model)
+	if !ok {
+		return 0, false
+	}
+
+	return price.Cost(r.Usage), true
+}
+
+// Cost returns the cost in USD of all generated images and true if their price is known.
+func (ir *ImageResponse) Cost() (float64, bool) {
+	price, ok := LookupPrice(ir.model)
+	if !ok {
+		return 0, false
+	}
+
+	cost, ok := price.ImageCost(ir.size, ir.quality)
+	if !ok {
+		return 0, false
+	}
+
+	return cost * float64(len(ir.Data)), true
+}
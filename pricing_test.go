@@ -0,0 +1,213 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func equalCost(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
+
+func TestPriceCost(t *testing.T) {
+	price := Price{Model: "test", Input: 2, CachedInput: 1, Output: 10, Reasoning: 20}
+
+	testCases := []struct {
+		name     string
+		price    Price
+		usage    Usage
+		expected float64
+	}{
+		{
+			name:     "empty",
+			price:    price,
+			expected: 0,
+		},
+		{
+			name:     "tokens",
+			price:    price,
+			usage:    Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000, TotalTokens: 1_500_000},
+			expected: 2 + 5,
+		},
+		{
+			name:  "details",
+			price: price,
+			usage: Usage{
+				PromptTokens:            1_000_000,
+				CompletionTokens:        500_000,
+				PromptTokensDetails:     PromptTokensDetails{CachedTokens: 400_000},
+				CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 100_000},
+			},
+			expected: 1.2 + 0.4 + 4 + 2,
+		},
+		{
+			name:     "deepseek cache",
+			price:    price,
+			usage:    Usage{PromptTokens: 1_000_000, PromptCacheHitTokens: 500_000},
+			expected: 1 + 0.5,
+		},
+		{
+			name:  "default prices",
+			price: Price{Model: "test", Input: 2, Output: 10},
+			usage: Usage{
+				PromptTokens:            1_000_000,
+				CompletionTokens:        1_000_000,
+				PromptTokensDetails:     PromptTokensDetails{CachedTokens: 500_000},
+				CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 500_000},
+			},
+			expected: 2 + 10,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if cost := tc.price.Cost(tc.usage); !equalCost(cost, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, cost)
+			}
+		})
+	}
+}
+
+func TestRegisterPrice(t *testing.T) {
+	const model Model = "test-priced-model"
+
+	images := map[ImageVariant]float64{{Size: ImageSize512, Quality: ImageQualityStandard}: 0.5}
+	if err := RegisterPrice(Price{Model: model, Input: 1, Output: 2, Images: images}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	images[ImageVariant{Size: ImageSize1024, Quality: ImageQualityStandard}] = 1
+
+	price, ok := LookupPrice(model)
+	if !ok || price.Input != 1 || price.Output != 2 {
+		t.Fatalf("unexpected price %v", price)
+	}
+
+	if cost, ok := price.ImageCost(ImageSize512, ImageQualityStandard); !ok || cost != 0.5 {
+		t.Errorf("unexpected image cost %v", cost)
+	}
+
+	if _, ok = price.ImageCost(ImageSize1024, ImageQualityStandard); ok {
+		t.Error("registered price must not be changed")
+	}
+
+	price.Images[ImageVariant{Size: ImageSize1024, Quality: ImageQualityStandard}] = 1
+	if price, _ = LookupPrice(model); len(price.Images) != 1 {
+		t.Error("registered price must not be changed by the lookup result")
+	}
+
+	failed := []Price{
+		{Model: " "},
+		{Model: model, Input: -1},
+		{Model: model, Images: map[ImageVariant]float64{{Size: ImageSize256}: -1}},
+	}
+
+	for _, p := range failed {
+		if err := RegisterPrice(p); !errors.Is(err, ErrRequiredParam) {
+			t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+		}
+	}
+
+	// all models of the package have prices
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for m := range registry.models {
+		if _, ok = LookupPrice(m); !ok && !strings.HasPrefix(string(m), "test-") {
+			t.Errorf("unknown price of model %q", m)
+		}
+	}
+}
+
+func TestResponseCost(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response string
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, completionsPath):
+			response = `{"id":"test","object":"chat.completion","created":1677652288,` +
+				`"choices":[{"index":0,"message":{"content":"Hello","role":"assistant"},"finish_reason":"stop"}],` +
+				`"usage":{"prompt_tokens":1000,"completion_tokens":2000,"total_tokens":3000,` +
+				`"prompt_tokens_details":{"cached_tokens":200}}}`
+		case strings.HasSuffix(r.URL.Path, imagesPath):
+			response = `{"created":1677652288,"data":[{"url":"https://localhost/1.png"},{"url":"https://localhost/2.png"}]}`
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()), WithDefaultModel(ModelGPT4oMini))
+	ctx := context.Background()
+
+	completion, err := client.Completion(ctx, &CompletionRequest{Messages: []Message{{Role: RoleUser, Content: "Hi"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 800 * 0.15 + 200 * 0.075 + 2000 * 0.6
+	if cost, ok := completion.Cost(); !ok || !equalCost(cost, 0.001335) {
+		t.Errorf("unexpected completion cost %v", cost)
+	}
+
+	if _, ok := (&CompletionResponse{model: "unknown"}).Cost(); ok {
+		t.Error("unexpected known cost")
+	}
+
+	testCases := []struct {
+		name     string
+		request  ImageRequest
+		expected float64
+		ok       bool
+	}{
+		{name: "default", request: ImageRequest{Prompt: "cat"}, expected: 0.04, ok: true},
+		{
+			name:     "hd",
+			request:  ImageRequest{Model: ModelDalle3, Prompt: "cat", Quality: ImageQualityHD},
+			expected: 0.16,
+			ok:       true,
+		},
+		{
+			name:     "auto",
+			request:  ImageRequest{Model: ModelGPTImage1, Prompt: "cat"},
+			expected: 0.5, // high quality 1536x1024 is the most expensive variant
+			ok:       true,
+		},
+		{
+			name:     "auto size",
+			request:  ImageRequest{Model: ModelGPTImage1, Prompt: "cat", Quality: ImageQualityLow},
+			expected: 0.032,
+			ok:       true,
+		},
+		{
+			name:     "auto quality",
+			request:  ImageRequest{Model: ModelGPTImage1, Prompt: "cat", Size: ImageSize1024},
+			expected: 0.334,
+			ok:       true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.Image(ctx, &tc.request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cost, ok := response.Cost()
+			if ok != tc.ok || !equalCost(cost, tc.expected) {
+				t.Errorf("expected %v (%v), got %v (%v)", tc.expected, tc.ok, cost, ok)
+			}
+		})
+	}
+}
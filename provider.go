@@ -0,0 +1,127 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// Provider is an API provider which is not compatible with OpenAI URLs or authentication.
+type Provider interface {
+	// URL returns the request URL for the OpenAI API path (for example, "/chat/completions") and the model.
+	URL(path string, model Model) (string, error)
+
+	// Authorize sets the authentication headers of the request.
+	Authorize(ctx context.Context, header http.Header) error
+}
+
+// extraProvider is a provider with extra parameters of chat completion requests.
+type extraProvider interface {
+	extra() map[string]any
+}
+
+// CompletionAdapter is a provider with its own chat completion request and response formats.
+// The request is validated before EncodeCompletion call. Streaming is not supported.
+type CompletionAdapter interface {
+	Provider
+
+	// EncodeCompletion returns the provider request body.
+	EncodeCompletion(r *CompletionRequest) ([]byte, error)
+
+	// DecodeCompletion converts the provider response body to the completion response.
+	DecodeCompletion(body io.Reader) (*CompletionResponse, error)
+}
+
+// encodeCompletion validates and encodes the request by the adapter.
+func encodeCompletion(adapter CompletionAdapter, r *CompletionRequest) (io.Reader, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := adapter.EncodeCompletion(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// decodeCompletion decodes the completion response by the params provider adapter or as OpenAI response.
+func decodeCompletion(body io.Reader, p *Params) (*CompletionResponse, error) {
+	adapter, ok := p.Provider.(CompletionAdapter)
+	if !ok {
+		response := &CompletionResponse{stopMarker: p.StopMarker}
+		if err := response.build(body); err != nil {
+			return nil, err
+		}
+
+		return response, nil
+	}
+
+	response, err := adapter.DecodeCompletion(body)
+	if err != nil {
+		return nil, errors.Join(ErrResponse, err)
+	}
+
+	if len(response.Choices) == 0 {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("empty response"))
+	}
+
+	response.CreatedTs = time.Unix(response.Created, 0)
+	response.stopMarker = p.StopMarker
+
+	return response, nil
+}
+
+// withExtra returns a copy of the request with the extra parameters, request parameters take precedence.
+func (c *CompletionRequest) withExtra(extra map[string]any) *CompletionRequest {
+	if len(extra) == 0 {
+		return c
+	}
+
+	request := *c
+	request.Extra = make(map[string]any, len(extra)+len(c.Extra))
+
+	maps.Copy(request.Extra, extra)
+	maps.Copy(request.Extra, c.Extra)
+
+	return &request
+}
+
+// mergeExtra adds the extra parameters to the JSON object data.
+func mergeExtra(data []byte, extra map[string]any) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+
+	for key, value := range extra {
+		if _, ok := fields[key]; ok {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("extra parameter %q overrides request field", key))
+		}
+
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal extra parameter %q: %w", key, err)
+		}
+
+		fields[key] = raw
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,94 @@
+package aoapi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReasoningEffort is a type of reasoning models effort.
+type ReasoningEffort string
+
+// Reasoning effort variants, ReasoningEffortMinimal is supported only by GPT-5 models.
+// DeepSeek, Anthropic and Gemini reasoning models do not support the reasoning effort.
+const (
+	ReasoningEffortMinimal ReasoningEffort = "minimal"
+	ReasoningEffortLow     ReasoningEffort = "low"
+	ReasoningEffortMedium  ReasoningEffort = "medium"
+	ReasoningEffortHigh    ReasoningEffort = "high"
+)
+
+// MarshalJSON implements the json.Marshaler interface.
+func (r *ReasoningEffort) MarshalJSON() ([]byte, error) {
+	return marshalJSON(r, ReasoningEffortMinimal, ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (r *ReasoningEffort) UnmarshalJSON(b []byte) error {
+	return unMarshalJSON(r, b, ReasoningEffortMinimal, ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh)
+}
+
+// Verbosity is a type of response verbosity.
+type Verbosity string
+
+// Verbosity variants.
+const (
+	VerbosityLow    Verbosity = "low"
+	VerbosityMedium Verbosity = "medium"
+	VerbosityHigh   Verbosity = "high"
+)
+
+// MarshalJSON implements the json.Marshaler interface.
+func (v *Verbosity) MarshalJSON() ([]byte, error) {
+	return marshalJSON(v, VerbosityLow, VerbosityMedium, VerbosityHigh)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *Verbosity) UnmarshalJSON(b []byte) error {
+	return unMarshalJSON(v, b, VerbosityLow, VerbosityMedium, VerbosityHigh)
+}
+
+// validateReasoning checks that the reasoning parameters are supported by the model.
+// Models without registry description are not validated.
+func (c *CompletionRequest) validateReasoning() error {
+	info, ok := LookupModel(c.Model)
+	if !ok {
+		return nil
+	}
+
+	if c.ReasoningEffort != "" && !info.Capabilities.Has(CapabilityReasoningEffort) {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model %q does not support reasoning effort", c.Model))
+	}
+
+	if c.ReasoningEffort == ReasoningEffortMinimal && !info.Capabilities.Has(CapabilityMinimalEffort) {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model %q does not support minimal reasoning effort", c.Model))
+	}
+
+	if c.Verbosity != "" && !info.Capabilities.Has(CapabilityVerbosity) {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("model %q does not support verbosity", c.Model))
+	}
+
+	return nil
+}
+
+// withoutSampling returns a copy of the request without sampling parameters if the model is a reasoning one,
+// such models reject them.
+func (c *CompletionRequest) withoutSampling() *CompletionRequest {
+	info, ok := LookupModel(c.Model)
+	if !ok || !info.Capabilities.Has(CapabilityReasoning) {
+		return c
+	}
+
+	if c.Temperature == nil && c.TopP == nil && c.PresencePenalty == nil &&
+		c.FrequencyPenalty == nil && c.LogitBias == nil {
+		return c
+	}
+
+	request := *c
+	request.Temperature = nil
+	request.TopP = nil
+	request.PresencePenalty = nil
+	request.FrequencyPenalty = nil
+	request.LogitBias = nil
+
+	return &request
+}
@@ -0,0 +1,124 @@
+package aoapi
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestCompletionRequestReasoning(t *testing.T) {
+	var (
+		temperature = float32(0.5)
+		topP        = float32(0.9)
+		messages    = []Message{{Role: RoleDeveloper, Content: "Be brief."}, {Role: RoleUser, Content: "Hello"}}
+	)
+
+	testCases := []struct {
+		name     string
+		request  CompletionRequest
+		expected string
+		err      bool
+	}{
+		{
+			name: "reasoning",
+			request: CompletionRequest{
+				Model:           ModelGPT5Mini,
+				Messages:        messages,
+				Temperature:     &temperature,
+				TopP:            &topP,
+				ReasoningEffort: ReasoningEffortMinimal,
+				Verbosity:       VerbosityLow,
+			},
+			expected: `{"model":"gpt-5-mini","messages":[{"role":"developer","content":"Be brief."},` +
+				`{"role":"user","content":"Hello"}],"reasoning_effort":"minimal","verbosity":"low"}`,
+		},
+		{
+			name:     "sampling",
+			request:  CompletionRequest{Model: ModelGPT4oMini, Messages: messages[1:], Temperature: &temperature},
+			expected: `{"model":"gpt-4o-mini","messages":[{"role":"user","content":"Hello"}],"temperature":0.5}`,
+		},
+		{
+			name: "unknown model",
+			request: CompletionRequest{
+				Model:           "local-model",
+				Messages:        messages[1:],
+				Temperature:     &temperature,
+				ReasoningEffort: ReasoningEffortHigh,
+			},
+			expected: `{"model":"local-model","messages":[{"role":"user","content":"Hello"}],` +
+				`"temperature":0.5,"reasoning_effort":"high"}`,
+		},
+		{
+			name:    "effort",
+			request: CompletionRequest{Model: ModelGPT4o, Messages: messages, ReasoningEffort: ReasoningEffortLow},
+			err:     true,
+		},
+		{
+			name:    "deepseek effort",
+			request: CompletionRequest{Model: ModelDeepSeekReasoner, Messages: messages, ReasoningEffort: ReasoningEffortLow},
+			err:     true,
+		},
+		{
+			name:    "claude effort",
+			request: CompletionRequest{Model: ModelClaudeSonnet4, Messages: messages, ReasoningEffort: ReasoningEffortHigh},
+			err:     true,
+		},
+		{
+			name: "minimal effort",
+			request: CompletionRequest{
+				Model:           ModelGPTo3Mini,
+				Messages:        messages,
+				ReasoningEffort: ReasoningEffortMinimal,
+			},
+			err: true,
+		},
+		{
+			name:    "verbosity",
+			request: CompletionRequest{Model: ModelGPTo3Mini, Messages: messages, Verbosity: VerbosityHigh},
+			err:     true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := tc.request.marshal()
+			if tc.err {
+				if !errors.Is(err, ErrRequiredParam) {
+					t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := string(data); s != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, s)
+			}
+
+			if tc.request.Temperature == nil {
+				t.Error("initial request must not be changed")
+			}
+		})
+	}
+
+	// unknown models of adapters are not validated, but the adapters do not silently drop the effort
+	for _, adapter := range []CompletionAdapter{&Anthropic{}, &Gemini{}} {
+		request := &CompletionRequest{Model: "new-model", Messages: messages[1:], ReasoningEffort: ReasoningEffortLow}
+		if _, err := adapter.EncodeCompletion(request); !errors.Is(err, ErrRequiredParam) {
+			t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+		}
+	}
+
+	invalid := CompletionRequest{Model: ModelGPT5, Messages: messages, ReasoningEffort: "extreme"}
+	if _, err := invalid.marshal(); !errors.Is(err, ErrMarshalJSON) {
+		t.Errorf("expected %v, got %v", ErrMarshalJSON, err)
+	}
+}
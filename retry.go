@@ -0,0 +1,181 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// defaultInitialBackoff is a delay before the second attempt if the policy does not set it.
+const defaultInitialBackoff = 500 * time.Millisecond
+
+// RetryPolicy is a struct of retry parameters for failed requests.
+// Requests are retried after transient transport errors (timeouts, reset or refused connections)
+// and responses with status codes 408, 409, 429 and 5xx.
+// MaxAttempts is a total number of attempts including the first one.
+// Zero InitialBackoff is replaced by 500ms to avoid retries without delay.
+// Jitter is a fraction of the delay which is randomly added or subtracted.
+type RetryPolicy struct {
+	MaxAttempts    uint
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+}
+
+// NewRetryPolicy returns a retry policy with default backoff parameters.
+func NewRetryPolicy(maxAttempts uint) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// RetryError is an error of the last failed attempt.
+type RetryError struct {
+	Attempts uint
+	Err      error
+}
+
+// Error returns the error message.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the last attempt error.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// retryHint is a server or transport hint for the next attempt.
+type retryHint struct {
+	retryable bool
+	after     time.Duration
+}
+
+// retryableStatus returns true if the request with the response status code can be retried.
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+
+	return statusCode >= http.StatusInternalServerError
+}
+
+// retryableError returns true if the transport error is transient and the request can be retried.
+// TLS, DNS and URL errors are permanent, they are not retried.
+func retryableError(err error) bool {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		// the connection is closed by the server before the response
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	}
+
+	return false
+}
+
+// newRetryHint returns a retry hint from the response status code and headers.
+// Retry-After, retry-after-ms and x-ratelimit-reset-* headers are used to get the delay.
+func newRetryHint(statusCode int, header http.Header) retryHint {
+	hint := retryHint{retryable: retryableStatus(statusCode)}
+	if !hint.retryable {
+		return hint
+	}
+
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		hint.after = time.Duration(ms * float64(time.Millisecond))
+		return hint
+	}
+
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+			hint.after = time.Duration(seconds * float64(time.Second))
+			return hint
+		}
+
+		if ts, err := http.ParseTime(value); err == nil {
+			hint.after = max(time.Until(ts), 0)
+			return hint
+		}
+	}
+
+	for _, key := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if d, err := time.ParseDuration(header.Get(key)); err == nil && d > hint.after {
+			hint.after = d
+		}
+	}
+
+	return hint
+}
+
+// backoff returns a delay before the next attempt.
+func (rp *RetryPolicy) backoff(attempt uint) time.Duration {
+	initial := rp.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+
+	multiplier := max(rp.Multiplier, 1)
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+
+	if rp.MaxBackoff > 0 {
+		delay = min(delay, float64(rp.MaxBackoff))
+	}
+
+	if rp.Jitter > 0 {
+		// #nosec G404 -- jitter does not need a cryptographically secure random
+		delay += delay * min(rp.Jitter, 1) * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// delay returns a delay before the next attempt and false if no more attempts are allowed.
+func (rp *RetryPolicy) delay(ctx context.Context, attempt uint, hint retryHint) (time.Duration, bool) {
+	if rp == nil || !hint.retryable || attempt >= rp.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	d := hint.after
+	if d == 0 {
+		d = rp.backoff(attempt)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(d).After(deadline) {
+		return 0, false
+	}
+
+	return d, true
+}
+
+// wait sleeps the delay or returns an error if the context is done.
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,309 @@
+package aoapi
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewRetryHint(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		headers    map[string]string
+		expected   retryHint
+	}{
+		{
+			name:       "bad request",
+			statusCode: http.StatusBadRequest,
+			headers:    map[string]string{"Retry-After": "1"},
+		},
+		{
+			name:       "server error",
+			statusCode: http.StatusBadGateway,
+			expected:   retryHint{retryable: true},
+		},
+		{
+			name:       "retry after seconds",
+			statusCode: http.StatusTooManyRequests,
+			headers:    map[string]string{"Retry-After": "2"},
+			expected:   retryHint{retryable: true, after: 2 * time.Second},
+		},
+		{
+			name:       "retry after ms",
+			statusCode: http.StatusTooManyRequests,
+			headers:    map[string]string{"Retry-After": "2", "retry-after-ms": "150"},
+			expected:   retryHint{retryable: true, after: 150 * time.Millisecond},
+		},
+		{
+			name:       "retry after date",
+			statusCode: http.StatusServiceUnavailable,
+			headers:    map[string]string{"Retry-After": "Wed, 21 Oct 2015 07:28:00 GMT"},
+			expected:   retryHint{retryable: true},
+		},
+		{
+			name:       "rate limit reset",
+			statusCode: http.StatusTooManyRequests,
+			headers: map[string]string{
+				"x-ratelimit-reset-requests": "1s",
+				"x-ratelimit-reset-tokens":   "6m0s",
+			},
+			expected: retryHint{retryable: true, after: 6 * time.Minute},
+		},
+		{
+			name:       "invalid headers",
+			statusCode: http.StatusRequestTimeout,
+			headers:    map[string]string{"Retry-After": "soon", "x-ratelimit-reset-tokens": "later"},
+			expected:   retryHint{retryable: true},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			header := make(http.Header)
+			for key, value := range tc.headers {
+				header.Set(key, value)
+			}
+
+			if hint := newRetryHint(tc.statusCode, header); hint != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, hint)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+
+	for i, e := range expected {
+		if d := policy.backoff(uint(i + 1)); d != e {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, e, d)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 5 {
+		if d := policy.backoff(1); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Errorf("unexpected jitter delay: %v", d)
+		}
+	}
+
+	if d := (&RetryPolicy{MaxAttempts: 5}).backoff(2); d != defaultInitialBackoff {
+		t.Errorf("expected %v, got %v", defaultInitialBackoff, d)
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "refused",
+			err:      &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			expected: true,
+		},
+		{name: "reset", err: &url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, expected: true},
+		{name: "unexpected EOF", err: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), expected: true},
+		{name: "EOF", err: &url.Error{Op: "Post", Err: io.EOF}, expected: true},
+		{name: "timeout", err: &net.DNSError{Err: "timeout", IsTimeout: true}, expected: true},
+		{name: "no such host", err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}},
+		{name: "certificate", err: &url.Error{Op: "Post", Err: x509.UnknownAuthorityError{}}},
+		{name: "scheme", err: &url.Error{Op: "Post", Err: errors.New(`unsupported protocol scheme "ftp"`)}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if r := retryableError(tc.err); r != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, r)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	ctx := context.Background()
+
+	if _, ok := (*RetryPolicy)(nil).delay(ctx, 1, retryHint{retryable: true}); ok {
+		t.Error("nil policy must not retry")
+	}
+
+	if _, ok := policy.delay(ctx, 1, retryHint{}); ok {
+		t.Error("not retryable hint must not retry")
+	}
+
+	if _, ok := policy.delay(ctx, 3, retryHint{retryable: true}); ok {
+		t.Error("attempts limit is exceeded")
+	}
+
+	if d, ok := policy.delay(ctx, 1, retryHint{retryable: true, after: time.Second}); !ok || d != time.Second {
+		t.Errorf("unexpected delay %v", d)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	if _, ok := policy.delay(ctx, 1, retryHint{retryable: true, after: time.Second}); ok {
+		t.Error("delay must not exceed context deadline")
+	}
+}
+
+func TestCompletionRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"error":{"message":"rate limit","code":"rate_limit_exceeded"}}`, http.StatusTooManyRequests)
+			return
+		}
+
+		response := `{"id":"test","object":"chat.completion","created":1677652288,` +
+			`"choices":[{"index":0,"message":{"content":"Message","role":"assistant"},"finish_reason":"stop"}]}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	request := &CompletionRequest{Model: ModelGPT4oMini, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	params := Params{URL: s.URL, Retry: NewRetryPolicy(3)}
+
+	response, err := Completion(context.Background(), s.Client(), request, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := response.String(); r != "Message" {
+		t.Errorf("unexpected response: %q", r)
+	}
+
+	if n := calls.Load(); n != 3 {
+		t.Errorf("unexpected calls number: %d", n)
+	}
+}
+
+func TestCompletionRetryFailed(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		attempts   uint
+	}{
+		{name: "server error", statusCode: http.StatusBadGateway, attempts: 3},
+		{name: "bad request", statusCode: http.StatusBadRequest, attempts: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				http.Error(w, "test", tc.statusCode)
+			}))
+			defer s.Close()
+
+			request := &CompletionRequest{Model: ModelGPT4oMini, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+			policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+			_, err := Completion(context.Background(), s.Client(), request, Params{URL: s.URL, Retry: policy})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			var retryErr *RetryError
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("expected retry error, got %T", err)
+			}
+
+			if retryErr.Attempts != tc.attempts || uint(calls.Load()) != tc.attempts {
+				t.Errorf("expected %d attempts, got %d (calls %d)", tc.attempts, retryErr.Attempts, calls.Load())
+			}
+
+			if !errors.Is(err, ErrResponse) {
+				t.Errorf("expected %v, got %v", ErrResponse, err)
+			}
+		})
+	}
+}
+
+func TestCompletionRetryTransport(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	secure := httptest.NewUnstartedServer(http.NotFoundHandler())
+	secure.Config.ErrorLog = log.New(io.Discard, "", 0)
+	secure.StartTLS()
+	defer secure.Close()
+
+	testCases := []struct {
+		name     string
+		url      string
+		attempts uint
+	}{
+		{name: "refused", url: closed.URL, attempts: 3},
+		{name: "certificate", url: secure.URL, attempts: 1},
+		{name: "scheme", url: "ftp://localhost", attempts: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := &CompletionRequest{Model: ModelGPT4oMini, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+			policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+			_, err := Completion(context.Background(), http.DefaultClient, request, Params{URL: tc.url, Retry: policy})
+
+			var retryErr *RetryError
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("expected retry error, got %v", err)
+			}
+
+			if retryErr.Attempts != tc.attempts {
+				t.Errorf("expected %d attempts, got %d: %v", tc.attempts, retryErr.Attempts, err)
+			}
+		})
+	}
+}
+
+func TestCompletionRetryCanceled(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test", http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	request := &CompletionRequest{Model: ModelGPT4oMini, Messages: []Message{{Role: RoleUser, Content: "Hello"}}}
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute}
+
+	_, err := Completion(ctx, s.Client(), request, Params{URL: s.URL, Retry: policy})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 1 {
+		t.Errorf("unexpected retry error: %v", err)
+	}
+}
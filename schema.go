@@ -0,0 +1,334 @@
+package aoapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrSchema is an error that occurs when a JSON schema can not be generated for a Go type.
+var ErrSchema = errors.New("failed JSON schema")
+
+// JSON schema types.
+const (
+	SchemaTypeObject  = "object"
+	SchemaTypeArray   = "array"
+	SchemaTypeString  = "string"
+	SchemaTypeInteger = "integer"
+	SchemaTypeNumber  = "number"
+	SchemaTypeBoolean = "boolean"
+	schemaTypeNull    = "null"
+)
+
+// Schema is a subset of JSON schema which is supported by structured outputs.
+// If Nullable is true, the value can be null too.
+type Schema struct {
+	Type                 string             `json:"-"`
+	Nullable             bool               `json:"-"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schemaAlias Schema
+
+	var schemaType any = s.Type
+	if s.Nullable {
+		schemaType = []string{s.Type, schemaTypeNull}
+	}
+
+	return json.Marshal(&struct {
+		Type any `json:"type"`
+		*schemaAlias
+	}{Type: schemaType, schemaAlias: (*schemaAlias)(s)})
+}
+
+// SchemaOf returns a JSON schema of the type T.
+// Struct fields names are taken from "json" tags, all fields are required,
+// pointer fields are nullable. Tags "description" and "enum" (comma separated values)
+// set the field description and allowed string values.
+func SchemaOf[T any]() (*Schema, error) {
+	return schemaOf(reflect.TypeFor[T](), nil)
+}
+
+func schemaOf(t reflect.Type, parents []reflect.Type) (*Schema, error) {
+	if t == reflect.TypeFor[time.Time]() {
+		return &Schema{Type: SchemaTypeString, Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s, err := schemaOf(t.Elem(), parents)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Nullable = true
+		return s, nil
+	case reflect.String:
+		return &Schema{Type: SchemaTypeString}, nil
+	case reflect.Bool:
+		return &Schema{Type: SchemaTypeBoolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: SchemaTypeInteger}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaTypeNumber}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as base64 string, but [N]byte is an array of numbers
+			return &Schema{Type: SchemaTypeString}, nil
+		}
+
+		items, err := schemaOf(t.Elem(), parents)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: SchemaTypeArray, Items: items}, nil
+	case reflect.Struct:
+		if slices.Contains(parents, t) {
+			return nil, errors.Join(ErrSchema, fmt.Errorf("recursive type %v is not supported", t))
+		}
+
+		return structSchema(t, append(parents, t))
+	default:
+		return nil, errors.Join(ErrSchema, fmt.Errorf("type %v is not supported", t))
+	}
+}
+
+func structSchema(t reflect.Type, parents []reflect.Type) (*Schema, error) {
+	var additional = false
+
+	fields, err := structFields(t, parents, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{
+		Type:                 SchemaTypeObject,
+		Properties:           make(map[string]*Schema, len(fields)),
+		AdditionalProperties: &additional,
+	}
+
+	for i := range fields {
+		if dominantField(fields, i) {
+			s.Properties[fields[i].name] = fields[i].schema
+			s.Required = append(s.Required, fields[i].name)
+		}
+	}
+
+	return s, nil
+}
+
+// schemaField is a struct field property, depth is a level of the embedded struct which contains the field.
+type schemaField struct {
+	name   string
+	depth  int
+	tagged bool
+	schema *Schema
+}
+
+// structFields returns properties of the struct fields including flattened fields of embedded structs.
+func structFields(t reflect.Type, parents []reflect.Type, depth int) ([]schemaField, error) {
+	fields := make([]schemaField, 0, t.NumField())
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		embedded := embeddedStruct(field)
+
+		if name == "-" || !(field.IsExported() || embedded != nil) {
+			continue
+		}
+
+		if embedded != nil && name == "" {
+			if slices.Contains(parents, embedded) {
+				return nil, errors.Join(ErrSchema, fmt.Errorf("recursive type %v is not supported", embedded))
+			}
+
+			inner, err := structFields(embedded, append(parents, embedded), depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			fields = append(fields, inner...)
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = field.Name
+		}
+
+		property, err := schemaOf(field.Type, parents)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("field %s.%s", t.Name(), field.Name))
+		}
+
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			if property.Type != SchemaTypeString {
+				return nil, errors.Join(
+					ErrSchema, fmt.Errorf("enum tag of non-string field %s.%s is not supported", t.Name(), field.Name),
+				)
+			}
+
+			property.Enum = strings.Split(enum, ",")
+		}
+
+		fields = append(fields, schemaField{name: name, depth: depth, tagged: tagged, schema: property})
+	}
+
+	return fields, nil
+}
+
+// dominantField returns true if the field is not hidden by other fields with the same name.
+// It follows encoding/json rules: the shallowest field wins, then the tagged one, and ambiguous fields are dropped.
+func dominantField(fields []schemaField, i int) bool {
+	for j := range fields {
+		if j == i || fields[j].name != fields[i].name || fields[j].depth > fields[i].depth {
+			continue
+		}
+
+		if fields[j].depth < fields[i].depth || fields[j].tagged || !fields[i].tagged {
+			return false
+		}
+	}
+
+	return true
+}
+
+// embeddedStruct returns the struct type of the embedded field which fields are flattened by encoding/json.
+// Embedded pointers to structs are flattened too, but only exported ones, because unexported are ignored.
+func embeddedStruct(field reflect.StructField) reflect.Type {
+	if !field.Anonymous {
+		return nil
+	}
+
+	switch t := field.Type; {
+	case t.Kind() == reflect.Struct:
+		return t
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct && field.IsExported():
+		return t.Elem()
+	default:
+		return nil
+	}
+}
+
+// Validate checks that JSON data matches the schema.
+func (s *Schema) Validate(data []byte) error {
+	var v any
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&v); err != nil {
+		return errors.Join(ErrResponse, fmt.Errorf("invalid JSON: %w", err))
+	}
+
+	if err := s.validate(v, "$"); err != nil {
+		return errors.Join(ErrResponse, err)
+	}
+
+	return nil
+}
+
+func (s *Schema) validate(v any, path string) error {
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+
+		return fmt.Errorf("%s must not be null", path)
+	}
+
+	switch s.Type {
+	case SchemaTypeObject:
+		return s.validateObject(v, path)
+	case SchemaTypeArray:
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be an array", path)
+		}
+
+		if s.Items == nil {
+			return nil
+		}
+
+		for i, item := range items {
+			if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case SchemaTypeString:
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return fmt.Errorf("%s value %q is not one of %v", path, str, s.Enum)
+		}
+	case SchemaTypeInteger:
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+	case SchemaTypeNumber:
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("%s must be a number", path)
+		}
+	case SchemaTypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateObject(v any, path string) error {
+	object, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s must be an object", path)
+	}
+
+	for _, key := range s.Required {
+		if _, ok = object[key]; !ok {
+			return fmt.Errorf("%s.%s is required", path, key)
+		}
+	}
+
+	for key, value := range object {
+		property, found := s.Properties[key]
+		if !found {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("%s.%s is not allowed", path, key)
+			}
+
+			continue
+		}
+
+		if err := property.validate(value, path+"."+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package aoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSchemaBase struct {
+	ID int `json:"id"`
+}
+
+type testSchemaItem struct {
+	Name  string  `json:"name" description:"Item name"`
+	Price float64 `json:"price"`
+}
+
+type testSchemaOrder struct {
+	testSchemaBase
+	Status   string           `json:"status" enum:"new,paid"`
+	Items    []testSchemaItem `json:"items"`
+	Comment  *string          `json:"comment,omitempty"`
+	Paid     bool             `json:"paid"`
+	Created  time.Time        `json:"created"`
+	Ignored  string           `json:"-"`
+	internal string
+}
+
+type TestSchemaMeta struct {
+	Tags []string `json:"tags"`
+}
+
+type testSchemaBytes struct {
+	*TestSchemaMeta
+	Data []byte  `json:"data"`
+	Hash [2]byte `json:"hash"`
+}
+
+type testSchemaInner struct {
+	Name int `json:"name"`
+	Age  int `json:"age"`
+	Note string
+}
+
+type testSchemaNote struct {
+	Note int
+}
+
+type testSchemaShadow struct {
+	Name string `json:"name"`
+	testSchemaInner
+	testSchemaNote
+}
+
+type testSchemaRecursive struct {
+	Children []testSchemaRecursive `json:"children"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema, err := SchemaOf[testSchemaOrder]()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"type":"object","properties":{` +
+		`"comment":{"type":["string","null"]},` +
+		`"created":{"type":"string","format":"date-time"},` +
+		`"id":{"type":"integer"},` +
+		`"items":{"type":"array","items":{"type":"object","properties":{` +
+		`"name":{"type":"string","description":"Item name"},"price":{"type":"number"}},` +
+		`"required":["name","price"],"additionalProperties":false}},` +
+		`"paid":{"type":"boolean"},` +
+		`"status":{"type":"string","enum":["new","paid"]}},` +
+		`"required":["id","status","items","comment","paid","created"],"additionalProperties":false}`
+
+	if s := string(data); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+}
+
+func TestSchemaOfEmbeddedPointer(t *testing.T) {
+	schema, err := SchemaOf[testSchemaBytes]()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"type":"object","properties":{` +
+		`"data":{"type":"string"},` +
+		`"hash":{"type":"array","items":{"type":"integer"}},` +
+		`"tags":{"type":"array","items":{"type":"string"}}},` +
+		`"required":["tags","data","hash"],"additionalProperties":false}`
+
+	if s := string(data); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+
+	value, err := json.Marshal(testSchemaBytes{TestSchemaMeta: &TestSchemaMeta{Tags: []string{"a"}}, Data: []byte("a")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = schema.Validate(value); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSchemaOfEmbeddedShadow(t *testing.T) {
+	schema, err := SchemaOf[testSchemaShadow]()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// outer name hides the inner one, ambiguous Note fields of the same depth are dropped
+	expected := `{"type":"object","properties":{"age":{"type":"integer"},"name":{"type":"string"}},` +
+		`"required":["name","age"],"additionalProperties":false}`
+
+	if s := string(data); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+
+	value, err := json.Marshal(testSchemaShadow{Name: "a", testSchemaInner: testSchemaInner{Name: 1, Age: 2, Note: "b"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = schema.Validate(value); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSchemaOfFailed(t *testing.T) {
+	if _, err := SchemaOf[testSchemaRecursive](); !errors.Is(err, ErrSchema) {
+		t.Errorf("expected %v, got %v", ErrSchema, err)
+	}
+
+	if _, err := SchemaOf[map[string]int](); !errors.Is(err, ErrSchema) {
+		t.Errorf("expected %v, got %v", ErrSchema, err)
+	}
+
+	_, err := SchemaOf[struct {
+		Value any `json:"value"`
+	}]()
+	if !errors.Is(err, ErrSchema) {
+		t.Errorf("expected %v, got %v", ErrSchema, err)
+	}
+
+	_, err = SchemaOf[struct {
+		Level int `json:"level" enum:"1,2"`
+	}]()
+	if !errors.Is(err, ErrSchema) || !strings.Contains(err.Error(), "enum tag of non-string field") {
+		t.Errorf("expected %v, got %v", ErrSchema, err)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := SchemaOf[testSchemaOrder]()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const valid = `"id":1,"status":"new","items":[{"name":"a","price":1.5}],"comment":null,` +
+		`"paid":false,"created":"2025-01-01T00:00:00Z"`
+
+	testCases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "valid", data: `{` + valid + `}`},
+		{name: "invalid json", data: `{"id":`, expected: "invalid JSON"},
+		{name: "not object", data: `[]`, expected: "$ must be an object"},
+		{name: "required", data: `{"id":1}`, expected: "$.status is required"},
+		{name: "additional", data: `{` + valid + `,"extra":1}`, expected: "$.extra is not allowed"},
+		{
+			name:     "enum",
+			data:     strings.Replace(`{`+valid+`}`, `"new"`, `"old"`, 1),
+			expected: `$.status value "old" is not one of [new paid]`,
+		},
+		{
+			name:     "integer",
+			data:     strings.Replace(`{`+valid+`}`, `"id":1`, `"id":1.5`, 1),
+			expected: "$.id must be an integer",
+		},
+		{
+			name:     "nested",
+			data:     strings.Replace(`{`+valid+`}`, `"price":1.5`, `"price":"1.5"`, 1),
+			expected: "$.items[0].price must be a number",
+		},
+		{
+			name:     "null",
+			data:     strings.Replace(`{`+valid+`}`, `"paid":false`, `"paid":null`, 1),
+			expected: "$.paid must not be null",
+		},
+		{
+			name:     "boolean",
+			data:     strings.Replace(`{`+valid+`}`, `"paid":false`, `"paid":0`, 1),
+			expected: "$.paid must be a boolean",
+		},
+		{
+			name:     "array",
+			data:     strings.Replace(`{`+valid+`}`, `"items":[{"name":"a","price":1.5}]`, `"items":{}`, 1),
+			expected: "$.items must be an array",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tc.data))
+			if err != nil {
+				if tc.expected == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !errors.Is(err, ErrResponse) {
+					t.Errorf("expected %v, got %v", ErrResponse, err)
+				}
+
+				if e := err.Error(); !strings.Contains(e, tc.expected) {
+					t.Errorf("expected %q, got %q", tc.expected, e)
+				}
+				return
+			}
+
+			if tc.expected != "" {
+				t.Fatalf("expected error %q", tc.expected)
+			}
+		})
+	}
+}
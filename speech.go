@@ -0,0 +1,115 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"unicode/utf8"
+)
+
+// speechInputMaxLength is the maximum number of characters of the speech input.
+const speechInputMaxLength = 4096
+
+// Voice is a type of text-to-speech voice.
+type Voice string
+
+// Text-to-speech voices, ballad, verse and some others are supported only by gpt-4o-mini-tts model.
+const (
+	VoiceAlloy   Voice = "alloy"
+	VoiceAsh     Voice = "ash"
+	VoiceBallad  Voice = "ballad"
+	VoiceCoral   Voice = "coral"
+	VoiceEcho    Voice = "echo"
+	VoiceFable   Voice = "fable"
+	VoiceNova    Voice = "nova"
+	VoiceOnyx    Voice = "onyx"
+	VoiceSage    Voice = "sage"
+	VoiceShimmer Voice = "shimmer"
+	VoiceVerse   Voice = "verse"
+)
+
+// SpeechFormat is a type of text-to-speech audio format.
+type SpeechFormat string
+
+// Text-to-speech audio formats, pcm is raw 24kHz 16-bit signed little-endian samples without a header.
+const (
+	SpeechMP3  SpeechFormat = "mp3"
+	SpeechOpus SpeechFormat = "opus"
+	SpeechAAC  SpeechFormat = "aac"
+	SpeechFLAC SpeechFormat = "flac"
+	SpeechWAV  SpeechFormat = "wav"
+	SpeechPCM  SpeechFormat = "pcm"
+)
+
+// SpeechRequest is a request of text-to-speech API.
+// Instructions control the voice tone and style, they are not supported by tts-1 and tts-1-hd models.
+// Speed is in range [0.25, 4.0], 1.0 is used by default. Mp3 response format is used by default.
+type SpeechRequest struct {
+	Model          Model        `json:"model"`
+	Voice          Voice        `json:"voice"`
+	Input          string       `json:"input"`
+	Instructions   string       `json:"instructions,omitempty"`
+	ResponseFormat SpeechFormat `json:"response_format,omitempty"`
+	Speed          *float32     `json:"speed,omitempty"`
+}
+
+// validate checks the speech request parameters.
+func (r *SpeechRequest) validate() error {
+	formats := []SpeechFormat{SpeechMP3, SpeechOpus, SpeechAAC, SpeechFLAC, SpeechWAV, SpeechPCM}
+
+	switch {
+	case r.Model == "":
+		return fmt.Errorf("model must not be empty")
+	case r.Voice == "":
+		return fmt.Errorf("voice must not be empty")
+	case r.Input == "":
+		return fmt.Errorf("input must not be empty")
+	case utf8.RuneCountInString(r.Input) > speechInputMaxLength:
+		return fmt.Errorf("input must not be longer than %d characters", speechInputMaxLength)
+	case r.ResponseFormat != "" && !slices.Contains(formats, r.ResponseFormat):
+		return fmt.Errorf("response format %q is not supported", r.ResponseFormat)
+	case r.Speed != nil && (*r.Speed < 0.25 || *r.Speed > 4):
+		return fmt.Errorf("speed must be in range [0.25, 4]")
+	case r.Instructions != "" && (r.Model == ModelTTS1 || r.Model == ModelTTS1HD):
+		return fmt.Errorf("model %q does not support instructions", r.Model)
+	}
+
+	if _, known := LookupModel(r.Model); known && !r.Model.Has(CapabilitySpeech) {
+		return fmt.Errorf("model %q is not allowed for speech requests", r.Model)
+	}
+
+	return nil
+}
+
+func (r *SpeechRequest) marshal() (io.Reader, error) {
+	if err := r.validate(); err != nil {
+		return nil, errors.Join(ErrRequiredParam, err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal speech request: %w", err)
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+func (r *SpeechRequest) build(ctx context.Context, auth *Params) (*http.Request, error) {
+	body, err := r.marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return newRequest(ctx, auth, r.Model, body, "application/json")
+}
+
+// Speech sends request to the text-to-speech API and returns the audio stream of the response format.
+// A caller must close the stream if no error.
+func Speech(ctx context.Context, client *http.Client, r *SpeechRequest, p Params) (io.ReadCloser, error) {
+	return newParamsClient(client, p).Speech(ctx, r)
+}
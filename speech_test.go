@@ -0,0 +1,149 @@
+package aoapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSpeech(t *testing.T) {
+	audio := []byte{0xff, 0xf3, 0x44, 0xc4, 0x00, 0x7b}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != speechPath {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+
+		request := &SpeechRequest{}
+		if err = json.Unmarshal(data, request); err != nil {
+			t.Fatalf("failed to unmarshal body: %v", err)
+		}
+
+		if request.Input == "fail" {
+			http.Error(w, `{"error":{"message":"failed","type":"invalid_request_error"}}`, http.StatusBadRequest)
+			return
+		}
+
+		expected := `{"model":"gpt-4o-mini-tts","voice":"coral","input":"Hello","instructions":"Speak slowly",` +
+			`"response_format":"wav","speed":1.5}`
+		if s := string(data); s != expected {
+			t.Errorf("expected %s, got %s", expected, s)
+		}
+
+		w.Header().Set("Content-Type", "audio/wav")
+		if _, err = w.Write(audio); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	speed := float32(1.5)
+	client := NewClient(WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	request := &SpeechRequest{
+		Model:          ModelGPT4oMiniTTS,
+		Voice:          VoiceCoral,
+		Input:          "Hello",
+		Instructions:   "Speak slowly",
+		ResponseFormat: SpeechWAV,
+		Speed:          &speed,
+	}
+
+	stream, err := client.Speech(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+
+	if err = stream.Close(); err != nil {
+		t.Errorf("failed to close stream: %v", err)
+	}
+
+	if !bytes.Equal(data, audio) {
+		t.Errorf("unexpected audio %v", data)
+	}
+
+	request.Input = "fail"
+	_, err = Speech(context.Background(), s.Client(), request, Params{URL: s.URL + speechPath})
+	if !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+}
+
+func TestSpeechFailed(t *testing.T) {
+	var (
+		slow = float32(0.1)
+		long = strings.Repeat("ы", speechInputMaxLength+1)
+	)
+
+	testCases := []struct {
+		name    string
+		request SpeechRequest
+		err     string
+	}{
+		{
+			name:    "model",
+			request: SpeechRequest{Voice: VoiceAlloy, Input: "Hello"},
+			err:     "model must not be empty",
+		},
+		{
+			name:    "voice",
+			request: SpeechRequest{Model: ModelTTS1, Input: "Hello"},
+			err:     "voice must not be empty",
+		},
+		{
+			name:    "input",
+			request: SpeechRequest{Model: ModelTTS1, Voice: VoiceAlloy},
+			err:     "input must not be empty",
+		},
+		{
+			name:    "long input",
+			request: SpeechRequest{Model: ModelTTS1, Voice: VoiceAlloy, Input: long},
+			err:     "input must not be longer than 4096 characters",
+		},
+		{
+			name:    "format",
+			request: SpeechRequest{Model: ModelTTS1, Voice: VoiceAlloy, Input: "Hello", ResponseFormat: "ogg"},
+			err:     `response format "ogg" is not supported`,
+		},
+		{
+			name:    "speed",
+			request: SpeechRequest{Model: ModelTTS1, Voice: VoiceAlloy, Input: "Hello", Speed: &slow},
+			err:     "speed must be in range [0.25, 4]",
+		},
+		{
+			name:    "instructions",
+			request: SpeechRequest{Model: ModelTTS1HD, Voice: VoiceAlloy, Input: "Hello", Instructions: "Whisper"},
+			err:     `model "tts-1-hd" does not support instructions`,
+		},
+		{
+			name:    "not speech model",
+			request: SpeechRequest{Model: ModelWhisper1, Voice: VoiceAlloy, Input: "Hello"},
+			err:     `model "whisper-1" is not allowed for speech requests`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Speech(context.Background(), http.DefaultClient, &tc.request, Params{URL: ":"})
+			if !errors.Is(err, ErrRequiredParam) || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
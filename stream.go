@@ -0,0 +1,308 @@
+package aoapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// streamDataPrefix is a prefix of server-sent events data lines.
+	streamDataPrefix = "data:"
+	// streamDone is a data payload of the final server-sent event.
+	streamDone = "[DONE]"
+)
+
+// StreamOptions is a struct of streaming options.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Delta is a struct of a partial message from the streamed chunk.
+type Delta struct {
+	Role             Role            `json:"role,omitempty"`
+	Content          string          `json:"content,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+	ToolCalls        []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// ChunkChoice is a struct of the streamed chunk choice.
+// FinishReason is nil for all chunks except the last one of the choice.
+type ChunkChoice struct {
+	Index        int           `json:"index"`
+	Delta        Delta         `json:"delta"`
+	FinishReason *FinishReason `json:"finish_reason"`
+}
+
+// CompletionChunk is a struct of the streamed response chunk.
+// Usage is set only for the last chunk if it was requested by StreamOptions.
+type CompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Choices []ChunkChoice `json:"choices"`
+	Usage   *Usage        `json:"usage,omitempty"`
+}
+
+// chunkEnvelope is used to detect error events in the stream.
+type chunkEnvelope struct {
+	CompletionChunk
+	Error *ErrorInfo `json:"error,omitempty"`
+}
+
+// Stream is a server-sent events reader of the chat completion API.
+// It is not safe for concurrent use.
+type Stream struct {
+	ctx      context.Context
+	body     io.ReadCloser
+	reader   *bufio.Reader
+	response *CompletionResponse
+	choices  map[int]*Choice
+	done     bool
+	err      error
+	onClose  func(usage Usage)
+}
+
+func newStream(ctx context.Context, body io.ReadCloser, stopMarker string) *Stream {
+	return &Stream{
+		ctx:      ctx,
+		body:     body,
+		reader:   bufio.NewReader(body),
+		response: &CompletionResponse{stopMarker: stopMarker},
+		choices:  make(map[int]*Choice),
+	}
+}
+
+// readEvent reads the next server-sent event and returns its joined data lines.
+// Comments, event names, ids and retry fields are ignored.
+func (s *Stream) readEvent() (string, error) {
+	var data []string
+
+	for {
+		line, err := s.reader.ReadString('\n')
+
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, streamDataPrefix) {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, streamDataPrefix), " "))
+		} else if line == "" && len(data) > 0 && err == nil {
+			return strings.Join(data, "\n"), nil
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(data) > 0 {
+				return strings.Join(data, "\n"), nil
+			}
+
+			return "", err
+		}
+	}
+}
+
+// Recv returns the next chunk of the stream.
+// It returns io.EOF after the final "[DONE]" event.
+func (s *Stream) Recv() (*CompletionChunk, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		s.err = err
+		return nil, err
+	}
+
+	data, err := s.readEvent()
+	if err != nil {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		} else if errors.Is(err, io.EOF) {
+			err = errors.Join(ErrResponse, fmt.Errorf("stream closed before %s", streamDone))
+		} else {
+			err = fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		s.err = err
+		return nil, err
+	}
+
+	if data == streamDone {
+		s.done = true
+		return nil, io.EOF
+	}
+
+	envelope := &chunkEnvelope{}
+	if err = json.Unmarshal([]byte(data), envelope); err != nil {
+		s.err = errors.Join(ErrResponse, fmt.Errorf("failed to unmarshal chunk: %w", err))
+		return nil, s.err
+	}
+
+	if envelope.Error != nil {
+		s.err = errors.Join(ErrResponse, &ResponseError{E: *envelope.Error})
+		return nil, s.err
+	}
+
+	chunk := &envelope.CompletionChunk
+	if err = s.append(chunk); err != nil {
+		s.err = err
+		return nil, s.err
+	}
+
+	return chunk, nil
+}
+
+// append accumulates the chunk data to the final response.
+func (s *Stream) append(chunk *CompletionChunk) error {
+	r := s.response
+
+	if r.ID == "" {
+		r.ID = chunk.ID
+		r.Created = chunk.Created
+	}
+
+	if chunk.Usage != nil {
+		r.Usage = *chunk.Usage
+	}
+
+	for _, c := range chunk.Choices {
+		choice, ok := s.choices[c.Index]
+		if !ok {
+			choice = &Choice{Index: c.Index}
+			s.choices[c.Index] = choice
+		}
+
+		if c.Delta.Role != "" {
+			choice.Message.Role = c.Delta.Role
+		}
+
+		choice.Message.Content += c.Delta.Content
+		choice.Message.ReasoningContent += c.Delta.ReasoningContent
+
+		for _, tc := range c.Delta.ToolCalls {
+			if err := appendToolCall(&choice.Message, tc); err != nil {
+				return err
+			}
+		}
+
+		if c.FinishReason != nil {
+			choice.FinishReason = *c.FinishReason
+		}
+	}
+
+	return nil
+}
+
+// appendToolCall merges the partial tool call to the message tool calls.
+// Tool calls are streamed in order, so the index can only point to an existing call or the next one.
+func appendToolCall(m *Message, tc ToolCallDelta) error {
+	if tc.Index < 0 {
+		return nil
+	}
+
+	if n := len(m.ToolCalls); tc.Index > n {
+		return errors.Join(ErrResponse, fmt.Errorf("tool call index %d is out of range, expected at most %d", tc.Index, n))
+	}
+
+	if tc.Index == len(m.ToolCalls) {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{})
+	}
+
+	call := &m.ToolCalls[tc.Index]
+	if tc.ID != "" {
+		call.ID = tc.ID
+	}
+
+	if tc.Type != "" {
+		call.Type = tc.Type
+	}
+
+	call.Function.Name += tc.Function.Name
+	call.Function.Arguments += tc.Function.Arguments
+
+	return nil
+}
+
+// Chunks returns an iterator over the stream chunks.
+// The iteration stops after the first error, io.EOF is not returned.
+func (s *Stream) Chunks() iter.Seq2[*CompletionChunk, error] {
+	return func(yield func(*CompletionChunk, error) bool) {
+		for {
+			chunk, err := s.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if !yield(chunk, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Response reads the rest of the stream and returns the assembled completion response.
+// The stream is closed after this call.
+func (s *Stream) Response() (*CompletionResponse, error) {
+	defer func() {
+		_ = s.Close()
+	}()
+
+	for {
+		if _, err := s.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+	}
+
+	if len(s.choices) == 0 {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("empty response"))
+	}
+
+	r := s.response
+	r.Object = "chat.completion"
+	r.CreatedTs = time.Unix(r.Created, 0)
+	r.Choices = make([]Choice, 0, len(s.choices))
+
+	for _, choice := range s.choices {
+		r.Choices = append(r.Choices, *choice)
+	}
+
+	sort.Slice(r.Choices, func(i, j int) bool {
+		return r.Choices[i].Index < r.Choices[j].Index
+	})
+
+	return r, nil
+}
+
+// Close closes the stream body.
+func (s *Stream) Close() error {
+	if onClose := s.onClose; onClose != nil {
+		s.onClose = nil
+		onClose(s.response.Usage)
+	}
+
+	return s.body.Close()
+}
+
+// CompletionStream sends a streaming request to the API and returns a stream of chunks.
+// A caller must close the stream if no error.
+func CompletionStream(ctx context.Context, client *http.Client, r *CompletionRequest, p Params) (*Stream, error) {
+	return newParamsClient(client, p).CompletionStream(ctx, r)
+}
+
+// isStream returns true if the request expects a server-sent events response.
+func (c *CompletionRequest) isStream() bool {
+	return c.Stream != nil && *c.Stream
+}
@@ -0,0 +1,240 @@
+package aoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testStreamBody = `: keep-alive comment
+
+data: {"id":"test","object":"chat.completion.chunk","created":1677652288,` +
+	`"choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}
+
+data: {"id":"test","object":"chat.completion.chunk","created":1677652288,` +
+	`"choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null}]}
+
+event: message
+data: {"id":"test","object":"chat.completion.chunk","created":1677652288,` +
+	`"choices":[{"index":0,"delta":{"content":", world"},"finish_reason":null}]}
+
+data: {"id":"test","object":"chat.completion.chunk","created":1677652288,` +
+	`"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}
+
+data: {"id":"test","object":"chat.completion.chunk","created":1677652288,"choices":[],` +
+	`"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}
+
+data: [DONE]
+
+`
+
+func streamServer(t *testing.T, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept"); accept != "text/event-stream" {
+			t.Errorf("failed accept header: %q", accept)
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if s := string(data); !strings.Contains(s, `"stream":true`) {
+			t.Errorf("stream is not enabled: %s", s)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if _, err = fmt.Fprint(w, body); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestCompletionStream(t *testing.T) {
+	s := streamServer(t, testStreamBody)
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Model:    ModelGPT4oMini,
+		Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+	}
+	params := Params{Bearer: "test", URL: s.URL, StopMarker: "..."}
+
+	stream, err := CompletionStream(context.Background(), s.Client(), request, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.Stream != nil {
+		t.Error("initial request must not be changed")
+	}
+
+	var content strings.Builder
+	for chunk, chunkErr := range stream.Chunks() {
+		if chunkErr != nil {
+			t.Fatalf("unexpected error: %v", chunkErr)
+		}
+
+		for _, c := range chunk.Choices {
+			content.WriteString(c.Delta.Content)
+		}
+	}
+
+	if c := content.String(); c != "Hello, world" {
+		t.Errorf("unexpected content: %q", c)
+	}
+
+	if _, err = stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got %v", err)
+	}
+
+	response, err := stream.Response()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := response.String(); r != "Hello, world..." {
+		t.Errorf("unexpected response: %q", r)
+	}
+
+	expected := "prompt tokens: 4, completion tokens: 6, total tokens: 10"
+	if u := response.UsageInfo(); u != expected {
+		t.Errorf("expected %q, got %q", expected, u)
+	}
+
+	if response.ID != "test" || response.Choices[0].Message.Role != RoleAssistant {
+		t.Errorf("unexpected response: %#v", response)
+	}
+}
+
+func TestCompletionWithStream(t *testing.T) {
+	s := streamServer(t, testStreamBody)
+	defer s.Close()
+
+	stream := true
+	request := &CompletionRequest{
+		Model:    ModelGPT4oMini,
+		Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+		Stream:   &stream,
+	}
+
+	response, err := Completion(context.Background(), s.Client(), request, Params{Bearer: "test", URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := response.String(); r != "Hello, world" {
+		t.Errorf("unexpected response: %q", r)
+	}
+
+	if fr := response.Choices[0].FinishReason; fr != FinishReasonLength {
+		t.Errorf("unexpected finish reason: %q", fr)
+	}
+}
+
+func TestCompletionStreamFailed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "no done",
+			body:     "data: {\"id\":\"test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"a\"}}]}\n\n",
+			expected: "stream closed before [DONE]",
+		},
+		{
+			name:     "invalid json",
+			body:     "data: {\"id\":\n\n",
+			expected: "failed to unmarshal chunk",
+		},
+		{
+			name:     "error event",
+			body:     "data: {\"error\":{\"message\":\"overloaded\",\"type\":\"server_error\"}}\n\n",
+			expected: "overloaded",
+		},
+		{
+			name: "tool call index",
+			body: "data: {\"id\":\"test\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":" +
+				"[{\"index\":1000000000,\"function\":{\"name\":\"f\"}}]}}]}\n\n",
+			expected: "tool call index 1000000000 is out of range, expected at most 0",
+		},
+		{
+			name:     "empty",
+			body:     "data: [DONE]\n\n",
+			expected: "empty response",
+		},
+	}
+
+	for _, testCase := range testCases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			s := streamServer(t, tc.body)
+			defer s.Close()
+
+			request := &CompletionRequest{
+				Model:    ModelGPT4oMini,
+				Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+			}
+
+			stream, err := CompletionStream(context.Background(), s.Client(), request, Params{URL: s.URL})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = stream.Response()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if !errors.Is(err, ErrResponse) {
+				t.Errorf("expected %v, got %v", ErrResponse, err)
+			}
+
+			if e := err.Error(); !strings.Contains(e, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, e)
+			}
+		})
+	}
+}
+
+func TestCompletionStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "data: {\"id\":\"test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"a\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Model:    ModelGPT4oMini,
+		Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+	}
+
+	stream, err := CompletionStream(ctx, s.Client(), request, Params{URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	if _, err = stream.Recv(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+
+	if _, err = stream.Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,151 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+)
+
+// schemaNameRegexp matches characters which are not allowed in a JSON schema name.
+var schemaNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ResponseFormatType is a type of completion response format.
+type ResponseFormatType string
+
+// Response format types.
+const (
+	ResponseFormatText       ResponseFormatType = "text"
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+)
+
+// MarshalJSON implements the json.Marshaler interface.
+func (f *ResponseFormatType) MarshalJSON() ([]byte, error) {
+	return marshalJSON(f, ResponseFormatText, ResponseFormatJSONObject, ResponseFormatJSONSchema)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (f *ResponseFormatType) UnmarshalJSON(b []byte) error {
+	return unMarshalJSON(f, b, ResponseFormatText, ResponseFormatJSONObject, ResponseFormatJSONSchema)
+}
+
+// JSONSchema is a struct of JSON schema for structured outputs.
+type JSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// ResponseFormat is a struct of completion response format.
+// JSONSchema is required only for ResponseFormatJSONSchema type.
+type ResponseFormat struct {
+	Type       ResponseFormatType `json:"type"`
+	JSONSchema *JSONSchema        `json:"json_schema,omitempty"`
+}
+
+// validate checks the response format parameters.
+func (f *ResponseFormat) validate() error {
+	if f.Type != ResponseFormatJSONSchema {
+		return nil
+	}
+
+	if f.JSONSchema == nil || f.JSONSchema.Name == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("json schema name must not be empty"))
+	}
+
+	return nil
+}
+
+// schemaName returns a JSON schema name for the type.
+func schemaName(t reflect.Type) string {
+	if name := schemaNameRegexp.ReplaceAllString(t.Name(), "_"); name != "" && name != "_" {
+		return name
+	}
+
+	return "response"
+}
+
+// CompletionInto sends a request with structured output JSON schema generated from the type T
+// and returns the first choice content decoded to T.
+// If the request already has ResponseFormat, it is used as is, but the content is still validated.
+func CompletionInto[T any](
+	ctx context.Context, client *http.Client, r *CompletionRequest, p Params,
+) (*T, *CompletionResponse, error) {
+	return ClientCompletionInto[T](ctx, newParamsClient(client, p), r)
+}
+
+// ClientCompletionInto is the same as CompletionInto, but it uses the client to send the request.
+func ClientCompletionInto[T any](ctx context.Context, c *Client, r *CompletionRequest) (*T, *CompletionResponse, error) {
+	schema, err := SchemaOf[T]()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if schema.Type != SchemaTypeObject || schema.Nullable {
+		return nil, nil, errors.Join(ErrSchema, fmt.Errorf("root schema must be an object"))
+	}
+
+	request := *r
+	if request.ResponseFormat == nil {
+		data, e := json.Marshal(schema)
+		if e != nil {
+			return nil, nil, errors.Join(ErrSchema, e)
+		}
+
+		strict := true
+		request.ResponseFormat = &ResponseFormat{
+			Type: ResponseFormatJSONSchema,
+			JSONSchema: &JSONSchema{
+				Name:   schemaName(reflect.TypeFor[T]()),
+				Schema: data,
+				Strict: &strict,
+			},
+		}
+	}
+
+	response, err := c.Completion(ctx, &request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, err := decodeInto[T](response, schema)
+	if err != nil {
+		return nil, response, err
+	}
+
+	return value, response, nil
+}
+
+// decodeInto validates and decodes the first choice content of the response.
+func decodeInto[T any](response *CompletionResponse, schema *Schema) (*T, error) {
+	message := response.Choices[0].Message
+
+	if message.Refusal != "" {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("model refused: %s", message.Refusal))
+	}
+
+	if response.Choices[0].FinishReason == FinishReasonLength {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("content is truncated by tokens limit"))
+	}
+
+	if message.Content == "" {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("empty content"))
+	}
+
+	data := []byte(message.Content)
+	if err := schema.Validate(data); err != nil {
+		return nil, err
+	}
+
+	value := new(T)
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, errors.Join(ErrResponse, fmt.Errorf("failed to unmarshal content: %w", err))
+	}
+
+	return value, nil
+}
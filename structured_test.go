@@ -0,0 +1,186 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testWeather struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+	Unit        string  `json:"unit" enum:"celsius,fahrenheit"`
+}
+
+func structuredServer(t *testing.T, message string, check func(body string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if check != nil {
+			check(string(data))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"id":"test","object":"chat.completion","created":1677652288,` +
+			`"choices":[{"index":0,"message":` + message + `,"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`
+
+		if _, err = fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestCompletionInto(t *testing.T) {
+	content, err := json.Marshal(`{"city":"Paris","temperature":21.5,"unit":"celsius"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := `{"role":"assistant","content":` + string(content) + `}`
+	s := structuredServer(t, message, func(body string) {
+		expected := `"response_format":{"type":"json_schema","json_schema":{"name":"testWeather",` +
+			`"schema":{"type":"object","properties":{"city":{"type":"string"},` +
+			`"temperature":{"type":"number"},"unit":{"type":"string","enum":["celsius","fahrenheit"]}},` +
+			`"required":["city","temperature","unit"],"additionalProperties":false},"strict":true}}`
+
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q to contain %q", body, expected)
+		}
+	})
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Model:    ModelGPT4oMini,
+		Messages: []Message{{Role: RoleUser, Content: "What is the weather in Paris?"}},
+	}
+
+	weather, response, err := CompletionInto[testWeather](context.Background(), s.Client(), request, Params{URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if request.ResponseFormat != nil {
+		t.Error("initial request must not be changed")
+	}
+
+	expected := testWeather{City: "Paris", Temperature: 21.5, Unit: "celsius"}
+	if *weather != expected {
+		t.Errorf("expected %v, got %v", expected, *weather)
+	}
+
+	if response.ID != "test" {
+		t.Errorf("unexpected response: %v", response)
+	}
+}
+
+func TestCompletionIntoFailed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{
+			name:     "refusal",
+			message:  `{"role":"assistant","content":null,"refusal":"I can not help"}`,
+			expected: "model refused: I can not help",
+		},
+		{
+			name:     "empty",
+			message:  `{"role":"assistant","content":""}`,
+			expected: "empty content",
+		},
+		{
+			name:     "invalid",
+			message:  `{"role":"assistant","content":"{\"city\":\"Paris\"}"}`,
+			expected: "$.temperature is required",
+		},
+		{
+			name:     "not json",
+			message:  `{"role":"assistant","content":"Paris"}`,
+			expected: "invalid JSON",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s := structuredServer(t, tc.message, nil)
+			defer s.Close()
+
+			request := &CompletionRequest{
+				Model:    ModelGPT4oMini,
+				Messages: []Message{{Role: RoleUser, Content: "What is the weather in Paris?"}},
+			}
+
+			_, response, err := CompletionInto[testWeather](context.Background(), s.Client(), request, Params{URL: s.URL})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if response == nil {
+				t.Error("response must be returned")
+			}
+
+			if !errors.Is(err, ErrResponse) {
+				t.Errorf("expected %v, got %v", ErrResponse, err)
+			}
+
+			if e := err.Error(); !strings.Contains(e, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, e)
+			}
+		})
+	}
+}
+
+func TestCompletionIntoJSONObject(t *testing.T) {
+	message := `{"role":"assistant","content":"{\"city\":\"Paris\",\"temperature\":20,\"unit\":\"celsius\"}"}`
+	s := structuredServer(t, message, func(body string) {
+		if expected := `"response_format":{"type":"json_object"}`; !strings.Contains(body, expected) {
+			t.Errorf("expected %q to contain %q", body, expected)
+		}
+	})
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Model:          ModelDeepSeekChat,
+		Messages:       []Message{{Role: RoleUser, Content: "What is the weather in Paris? Reply in JSON."}},
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject},
+	}
+
+	weather, _, err := CompletionInto[testWeather](context.Background(), s.Client(), request, Params{URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if weather.City != "Paris" || weather.Temperature != 20 {
+		t.Errorf("unexpected value: %v", weather)
+	}
+}
+
+func TestCompletionIntoInvalidSchema(t *testing.T) {
+	request := &CompletionRequest{
+		Model:    ModelGPT4oMini,
+		Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+	}
+
+	_, _, err := CompletionInto[[]string](context.Background(), http.DefaultClient, request, Params{URL: ":"})
+	if !errors.Is(err, ErrSchema) {
+		t.Errorf("expected %v, got %v", ErrSchema, err)
+	}
+
+	request.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONSchema}
+	_, _, err = CompletionInto[testWeather](context.Background(), http.DefaultClient, request, Params{URL: ":"})
+	if !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
@@ -0,0 +1,481 @@
+package aoapi
+
+import (
+	"bufio"
+	"compress/gzip"
+	"container/heap"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenEncoding is a name of BPE token encoding.
+type TokenEncoding string
+
+// Token encodings.
+const (
+	EncodingCL100kBase TokenEncoding = "cl100k_base"
+	EncodingO200kBase  TokenEncoding = "o200k_base"
+)
+
+// chat message framing overhead, it is the same for all current chat models
+const (
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3
+)
+
+// whitespace is a regexp class of unicode White_Space characters, it matches unicode.IsSpace.
+const whitespace = `\t\n\v\f\r \x{85}\p{Z}`
+
+// contractions is a regexp of English contractions suffixes.
+const contractions = `(?i:'s|'t|'re|'ve|'m|'ll|'d)`
+
+// Pre-tokenizer patterns of the encodings.
+// Go regexp does not support lookahead, so the last group "\s+(?!\S)|\s+" is handled by splitPieces.
+var tokenPatterns = map[TokenEncoding]*regexp.Regexp{
+	EncodingCL100kBase: regexp.MustCompile(
+		contractions +
+			`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+			`|\p{N}{1,3}` +
+			`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n]*` +
+			`|[` + whitespace + `]*[\r\n]+` +
+			`|([` + whitespace + `]+)`,
+	),
+	EncodingO200kBase: regexp.MustCompile(
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+` + contractions + `?` +
+			`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*` + contractions + `?` +
+			`|\p{N}{1,3}` +
+			`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n/]*` +
+			`|[` + whitespace + `]*[\r\n]+` +
+			`|([` + whitespace + `]+)`,
+	),
+}
+
+// encodingFiles contains gzip compressed tiktoken ranks of the encodings, see encodings/README.md.
+//
+//go:embed encodings
+var encodingFiles embed.FS
+
+// embeddedTokenizers read the embedded ranks once on the first lookup of the encoding.
+var embeddedTokenizers = map[TokenEncoding]func() (*Tokenizer, error){
+	EncodingCL100kBase: embeddedTokenizer(EncodingCL100kBase),
+	EncodingO200kBase:  embeddedTokenizer(EncodingO200kBase),
+}
+
+// embeddedTokenizer returns a loader of the embedded encoding ranks which reads them only once.
+func embeddedTokenizer(name TokenEncoding) func() (*Tokenizer, error) {
+	return sync.OnceValues(func() (*Tokenizer, error) { return readEncoding(encodingFiles, name) })
+}
+
+// Tokenizer is a byte pair encoding tokenizer.
+// It is safe for concurrent use.
+type Tokenizer struct {
+	name    TokenEncoding
+	pattern *regexp.Regexp
+	ranks   map[string]int
+}
+
+// tokenizerRegistry is a thread-safe storage of loaded tokenizers.
+type tokenizerRegistry struct {
+	sync.RWMutex
+	tokenizers map[TokenEncoding]*Tokenizer
+}
+
+var tokenizers = &tokenizerRegistry{tokenizers: make(map[TokenEncoding]*Tokenizer)}
+
+// NewTokenizer returns a new tokenizer for the encoding with the merge ranks.
+// All single bytes must have ranks.
+func NewTokenizer(name TokenEncoding, ranks map[string]int) (*Tokenizer, error) {
+	pattern, ok := tokenPatterns[name]
+	if !ok {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("unknown token encoding %q", name))
+	}
+
+	for i := range 256 {
+		if _, ok = ranks[string([]byte{byte(i)})]; !ok {
+			return nil, errors.Join(ErrRequiredParam, fmt.Errorf("no rank for byte 0x%02x", i))
+		}
+	}
+
+	return &Tokenizer{name: name, pattern: pattern, ranks: ranks}, nil
+}
+
+// ReadTokenizer reads ranks in the tiktoken format: a base64 token and its rank per line.
+func ReadTokenizer(name TokenEncoding, r io.Reader) (*Tokenizer, error) {
+	var (
+		ranks   = make(map[string]int)
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" {
+			continue
+		}
+
+		token, value, found := strings.Cut(text, " ")
+		if !found {
+			return nil, fmt.Errorf("invalid ranks line %d: %q", line, text)
+		}
+
+		data, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token at line %d: %w", line, err)
+		}
+
+		rank, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank at line %d: %w", line, err)
+		}
+
+		ranks[string(data)] = rank
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ranks: %w", err)
+	}
+
+	return NewTokenizer(name, ranks)
+}
+
+// LoadTokenizer reads ranks of the encoding from the tiktoken file
+// (for example, "o200k_base.tiktoken") and registers the tokenizer.
+func LoadTokenizer(name TokenEncoding, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ranks file: %w", err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	t, err := ReadTokenizer(name, f)
+	if err != nil {
+		return err
+	}
+
+	RegisterTokenizer(t)
+	return nil
+}
+
+// readEncoding reads the tokenizer of the encoding from gzip compressed ranks of the file system.
+func readEncoding(fsys fs.FS, name TokenEncoding) (*Tokenizer, error) {
+	f, err := fsys.Open("encodings/" + string(name) + ".tiktoken.gz")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed ranks of %q: %w", name, err)
+	}
+
+	return ReadTokenizer(name, r)
+}
+
+// RegisterTokenizer adds or replaces the tokenizer of its encoding.
+// It is safe for concurrent use.
+func RegisterTokenizer(t *Tokenizer) {
+	tokenizers.Lock()
+	defer tokenizers.Unlock()
+
+	tokenizers.tokenizers[t.name] = t
+}
+
+// LookupTokenizer returns the registered tokenizer of the encoding.
+// The embedded tokenizer of the encoding is registered on the first lookup if it is available.
+func LookupTokenizer(name TokenEncoding) (*Tokenizer, bool) {
+	tokenizers.RLock()
+	t, ok := tokenizers.tokenizers[name]
+	tokenizers.RUnlock()
+
+	if ok {
+		return t, true
+	}
+
+	load, found := embeddedTokenizers[name]
+	if !found {
+		return nil, false
+	}
+
+	embedded, err := load()
+	if err != nil {
+		// ranks are not embedded, LoadTokenizer must be used
+		return nil, false
+	}
+
+	tokenizers.Lock()
+	defer tokenizers.Unlock()
+
+	if t, ok = tokenizers.tokenizers[name]; ok {
+		return t, true
+	}
+
+	tokenizers.tokenizers[name] = embedded
+	return embedded, true
+}
+
+// Encoding returns the token encoding of the model.
+// It is empty if the model is unknown or its tokenizer is not public, like Claude or Gemini ones.
+func (m Model) Encoding() TokenEncoding {
+	if info, ok := LookupModel(m); ok {
+		return info.Encoding
+	}
+
+	return ""
+}
+
+// Name returns the token encoding name.
+func (t *Tokenizer) Name() TokenEncoding {
+	return t.name
+}
+
+// Encode returns tokens of the text. Special tokens are encoded as ordinary text.
+func (t *Tokenizer) Encode(text string) []int {
+	tokens := make([]int, 0, len(text)/4+1)
+
+	for _, piece := range splitPieces(t.pattern, text) {
+		tokens = t.encodePiece(piece, tokens)
+	}
+
+	return tokens
+}
+
+// Count returns the number of tokens of the text.
+func (t *Tokenizer) Count(text string) int {
+	return len(t.Encode(text))
+}
+
+// encodePiece appends tokens of the pre-tokenized piece, merging the lowest rank pairs first.
+// Parts of the piece are a linked list and candidate pairs are kept in a heap,
+// so long pieces are encoded in O(n log n) instead of rescanning all pairs after every merge.
+func (t *Tokenizer) encodePiece(piece string, tokens []int) []int {
+	if rank, ok := t.ranks[piece]; ok {
+		return append(tokens, rank)
+	}
+
+	var (
+		n      = len(piece)
+		next   = make([]int, n) // start of the next part or n
+		prev   = make([]int, n) // start of the previous part or -1
+		merged = make([]bool, n)
+		pairs  = make(mergeHeap, 0, n)
+	)
+
+	for i := range n {
+		next[i], prev[i] = i+1, i-1
+	}
+
+	// push adds the pair of the part i and its next part if it is a known token
+	push := func(i int) {
+		if i < 0 || next[i] >= n {
+			return
+		}
+
+		end := next[next[i]]
+		if rank, ok := t.ranks[piece[i:end]]; ok {
+			heap.Push(&pairs, mergePair{rank: rank, start: i, end: end})
+		}
+	}
+
+	for i := range n - 1 {
+		push(i)
+	}
+
+	for pairs.Len() > 0 {
+		pair := heap.Pop(&pairs).(mergePair)
+
+		// the pair is outdated if one of its parts was already merged with another one
+		if merged[pair.start] || next[pair.start] >= n || next[next[pair.start]] != pair.end {
+			continue
+		}
+
+		right := next[pair.start]
+		merged[right] = true
+		next[pair.start] = next[right]
+
+		if next[right] < n {
+			prev[next[right]] = pair.start
+		}
+
+		push(prev[pair.start])
+		push(pair.start)
+	}
+
+	for i := 0; i < n; i = next[i] {
+		tokens = append(tokens, t.ranks[piece[i:next[i]]])
+	}
+
+	return tokens
+}
+
+// mergePair is a candidate pair of adjacent parts piece[start:end] with the rank of the merged token.
+type mergePair struct {
+	rank  int
+	start int
+	end   int
+}
+
+// mergeHeap is a min heap of merge pairs, the lowest rank and then the leftmost pair is the first.
+type mergeHeap []mergePair
+
+func (h mergeHeap) Len() int {
+	return len(h)
+}
+
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+
+	return h[i].start < h[j].start
+}
+
+func (h mergeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *mergeHeap) Push(x any) {
+	*h = append(*h, x.(mergePair))
+}
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+
+	return item
+}
+
+// splitPieces splits the text by the pre-tokenizer pattern.
+// A whitespace only piece matched by the last pattern group is shortened by one character
+// if it is followed by a non-whitespace, that emulates "\s+(?!\S)".
+func splitPieces(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+
+	for len(text) > 0 {
+		loc := pattern.FindStringSubmatchIndex(text)
+		if loc == nil || loc[1] == 0 {
+			// unreachable for the encoding patterns, but it avoids an infinite loop
+			_, size := utf8.DecodeRuneInString(text)
+			loc = []int{0, size}
+		}
+
+		start, end := loc[0], loc[1]
+		if len(loc) > 3 && loc[2] >= 0 && end < len(text) {
+			if next, _ := utf8.DecodeRuneInString(text[end:]); !unicode.IsSpace(next) {
+				if _, size := utf8.DecodeLastRuneInString(text[start:end]); end-size > start {
+					end -= size
+				}
+			}
+		}
+
+		if start > 0 {
+			// unmatched text is not possible, but keep it as a separate piece
+			pieces = append(pieces, text[:start])
+		}
+
+		pieces = append(pieces, text[start:end])
+		text = text[end:]
+	}
+
+	return pieces
+}
+
+// messageText returns all text of the message which is sent to the model.
+func messageText(m *Message) []string {
+	texts := []string{string(m.Role), m.Content}
+
+	for i := range m.Parts {
+		if m.Parts[i].Type == ContentPartText {
+			texts = append(texts, m.Parts[i].Text)
+		}
+	}
+
+	for i := range m.ToolCalls {
+		texts = append(texts, m.ToolCalls[i].Function.Name, m.ToolCalls[i].Function.Arguments)
+	}
+
+	if m.ToolCallID != "" {
+		texts = append(texts, m.ToolCallID)
+	}
+
+	return texts
+}
+
+// tokenCounter returns the text tokens counter of the model encoding or the estimation if it is not registered.
+func tokenCounter(model Model) func(string) int {
+	if t, ok := LookupTokenizer(model.Encoding()); ok {
+		return t.Count
+	}
+
+	return estimateTokens
+}
+
+// CountTokens returns the number of prompt tokens of the messages for the model,
+// including the chat message framing overhead.
+// Only text content is counted, images, audio and files are not.
+// If the tokenizer of the model encoding is not registered,
+// a pessimistic estimation is returned, use LoadTokenizer to register it.
+func CountTokens(messages []Message, model Model) int {
+	count := tokenCounter(model)
+
+	total := tokensPerReply
+	for i := range messages {
+		total += tokensPerMessage
+
+		for _, text := range messageText(&messages[i]) {
+			if text != "" {
+				total += count(text)
+			}
+		}
+
+		if name := messages[i].Name; name != "" {
+			total += count(name) + tokensPerName
+		}
+	}
+
+	return total
+}
+
+// checkContextWindow returns ErrContextLengthExceeded if the prompt and the max tokens
+// exceed the model context window. It is checked only if the model tokenizer is registered.
+func (c *CompletionRequest) checkContextWindow() error {
+	info, ok := LookupModel(c.Model)
+	if !ok || info.ContextWindow == 0 {
+		return nil
+	}
+
+	if _, ok = LookupTokenizer(c.Model.Encoding()); !ok {
+		return nil
+	}
+
+	if n := uint(CountTokens(c.Messages, c.Model)) + c.MaxTokens; n > info.ContextWindow {
+		return errors.Join(
+			ErrContextLengthExceeded,
+			fmt.Errorf("context window is %d tokens, but request needs %d", info.ContextWindow, n),
+		)
+	}
+
+	return nil
+}
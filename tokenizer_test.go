@@ -0,0 +1,429 @@
+package aoapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testRanks returns ranks of all single bytes and the extra tokens.
+func testRanks(extra ...string) map[string]int {
+	ranks := make(map[string]int, 256+len(extra))
+
+	for i := range 256 {
+		ranks[string([]byte{byte(i)})] = i
+	}
+
+	for i, token := range extra {
+		ranks[token] = 256 + i
+	}
+
+	return ranks
+}
+
+// registerTestTokenizer registers the tokenizer and restores the previous one after the test.
+func registerTestTokenizer(t *testing.T, tokenizer *Tokenizer) {
+	prev, ok := LookupTokenizer(tokenizer.Name())
+
+	t.Cleanup(func() {
+		tokenizers.Lock()
+		defer tokenizers.Unlock()
+
+		if ok {
+			tokenizers.tokenizers[prev.name] = prev
+		} else {
+			delete(tokenizers.tokenizers, tokenizer.name)
+		}
+	})
+
+	RegisterTokenizer(tokenizer)
+}
+
+func TestSplitPieces(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding TokenEncoding
+		text     string
+		expected []string
+	}{
+		{
+			name:     "cl100k words",
+			encoding: EncodingCL100kBase,
+			text:     "Hello world  how's it\n\n going 12345",
+			expected: []string{"Hello", " world", " ", " how", "'s", " it", "\n\n", " going", " ", "123", "45"},
+		},
+		{
+			name:     "cl100k case",
+			encoding: EncodingCL100kBase,
+			text:     "HelloWorld don't\tstop/\n",
+			expected: []string{"HelloWorld", " don", "'t", "\tstop", "/\n"},
+		},
+		{
+			name:     "o200k case",
+			encoding: EncodingO200kBase,
+			text:     "HelloWorld don't\tstop/\n",
+			expected: []string{"Hello", "World", " don't", "\tstop", "/\n"},
+		},
+		{
+			name:     "trailing spaces",
+			encoding: EncodingO200kBase,
+			text:     "a   ",
+			expected: []string{"a", "   "},
+		},
+		{
+			name:     "unicode spaces",
+			encoding: EncodingCL100kBase,
+			text:     "a　　b",
+			expected: []string{"a", "　", "　b"},
+		},
+		{
+			name:     "empty",
+			encoding: EncodingCL100kBase,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			pieces := splitPieces(tokenPatterns[tc.encoding], tc.text)
+
+			if !reflect.DeepEqual(pieces, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, pieces)
+			}
+		})
+	}
+}
+
+func TestTokenizerEncode(t *testing.T) {
+	tokenizer, err := NewTokenizer(EncodingCL100kBase, testRanks("he", "ll", "hell", " w", "or", " wor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		text     string
+		expected []int
+	}{
+		{text: "", expected: []int{}},
+		{text: "hello", expected: []int{258, 'o'}},
+		{text: "hello world", expected: []int{258, 'o', 261, 'l', 'd'}},
+		{text: "hell", expected: []int{258}},
+		{text: "ab", expected: []int{'a', 'b'}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.text, func(t *testing.T) {
+			tokens := tokenizer.Encode(tc.text)
+
+			if !reflect.DeepEqual(tokens, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, tokens)
+			}
+
+			if n := tokenizer.Count(tc.text); n != len(tc.expected) {
+				t.Errorf("expected %d, got %d", len(tc.expected), n)
+			}
+		})
+	}
+}
+
+func TestNewTokenizerFailed(t *testing.T) {
+	if _, err := NewTokenizer("p50k_base", testRanks()); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+
+	ranks := testRanks()
+	delete(ranks, "a")
+
+	if _, err := NewTokenizer(EncodingO200kBase, ranks); !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+	}
+}
+
+func TestLoadTokenizer(t *testing.T) {
+	var b strings.Builder
+	for token, rank := range testRanks("hi") {
+		_, _ = fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+
+	path := filepath.Join(t.TempDir(), "o200k_base.tiktoken")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, _ := LookupTokenizer(EncodingO200kBase)
+	t.Cleanup(func() {
+		if prev != nil {
+			RegisterTokenizer(prev)
+		} else {
+			tokenizers.Lock()
+			delete(tokenizers.tokenizers, EncodingO200kBase)
+			tokenizers.Unlock()
+		}
+	})
+
+	if err := LoadTokenizer(EncodingO200kBase, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tokenizer, ok := LookupTokenizer(EncodingO200kBase)
+	if !ok {
+		t.Fatal("tokenizer is not registered")
+	}
+
+	if tokens := tokenizer.Encode("hi"); !reflect.DeepEqual(tokens, []int{256}) {
+		t.Errorf("unexpected tokens %v", tokens)
+	}
+
+	if err := LoadTokenizer(EncodingO200kBase, filepath.Join(t.TempDir(), "unknown")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestReadTokenizerFailed(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "no rank", data: "aGk=\n"},
+		{name: "invalid base64", data: "!!! 1\n"},
+		{name: "invalid rank", data: "aGk= x\n"},
+		{name: "no bytes", data: "aGk= 1\n"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadTokenizer(EncodingCL100kBase, strings.NewReader(tc.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestModelEncoding(t *testing.T) {
+	testCases := []struct {
+		model    Model
+		expected TokenEncoding
+	}{
+		{model: ModelGPT4, expected: EncodingCL100kBase},
+		{model: ModelTextEmbedding3Small, expected: EncodingCL100kBase},
+		{model: ModelGPT4oMini, expected: EncodingO200kBase},
+		{model: ModelGPT5, expected: EncodingO200kBase},
+		{model: ModelDeepSeekChat},
+		{model: ModelClaudeSonnet4},
+		{model: ModelGemini25Flash},
+		{model: "unknown-model"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(string(tc.model), func(t *testing.T) {
+			if e := tc.model.Encoding(); e != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, e)
+			}
+		})
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	tokenizer, err := NewTokenizer(EncodingCL100kBase, testRanks())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []Message{
+		{Role: RoleSystem, Content: "hello"},
+		{Role: RoleUser, Parts: []ContentPart{TextPart("hello"), ImageURLPart("https://localhost/a.png", "")}, Name: "bob"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "1", Function: FunctionCall{Name: "f", Arguments: "{}"}}}},
+	}
+
+	if n := CountTokens(messages, ModelGPT4); n <= 0 {
+		t.Errorf("unexpected estimation %d", n)
+	}
+
+	registerTestTokenizer(t, tokenizer)
+
+	// reply 3, system 3+6+5, user 3+4+5+(3+1), assistant 3+9+1+2
+	if n := CountTokens(messages, ModelGPT4); n != 48 {
+		t.Errorf("expected 48, got %d", n)
+	}
+
+	// models without a public encoding are estimated by bytes: reply 3, user 3+4+11
+	messages = []Message{{Role: RoleUser, Content: "hello world"}}
+	if n := CountTokens(messages, ModelClaudeSonnet4); n != 21 {
+		t.Errorf("expected 21, got %d", n)
+	}
+
+	// reply 3, user 3+1+2
+	if n := CountTokens(messages, ModelGPT4oMini); n != 9 {
+		t.Errorf("expected 9, got %d", n)
+	}
+}
+
+func TestCompletionRequestContextWindow(t *testing.T) {
+	tokenizer, err := NewTokenizer(EncodingCL100kBase, testRanks())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := Model("test-context-window")
+	info := ModelInfo{Name: model, Capabilities: CapabilityChat, ContextWindow: 24, Encoding: EncodingCL100kBase}
+
+	if err = RegisterModel(info); err != nil {
+		t.Fatal(err)
+	}
+
+	// reply 3, user 3+4+5
+	request := &CompletionRequest{Model: model, Messages: []Message{{Role: RoleUser, Content: "hello"}}, MaxTokens: 10}
+	if _, err = request.marshal(); err != nil {
+		t.Fatalf("unexpected error without tokenizer: %v", err)
+	}
+
+	registerTestTokenizer(t, tokenizer)
+
+	if _, err = request.marshal(); !errors.Is(err, ErrContextLengthExceeded) {
+		t.Errorf("expected %v, got %v", ErrContextLengthExceeded, err)
+	}
+
+	request.MaxTokens = 9
+	if _, err = request.marshal(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// referenceEncodePiece is a straightforward quadratic BPE merge to check the heap based one.
+func referenceEncodePiece(ranks map[string]int, piece string) []int {
+	parts := make([]string, len(piece))
+	for i := range piece {
+		parts[i] = piece[i : i+1]
+	}
+
+	for {
+		minRank, minIndex := -1, -1
+
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := ranks[parts[i]+parts[i+1]]; ok && (minIndex < 0 || rank < minRank) {
+				minRank, minIndex = rank, i
+			}
+		}
+
+		if minIndex < 0 {
+			break
+		}
+
+		parts[minIndex] += parts[minIndex+1]
+		parts = append(parts[:minIndex+1], parts[minIndex+2:]...)
+	}
+
+	tokens := make([]int, len(parts))
+	for i, part := range parts {
+		tokens[i] = ranks[part]
+	}
+
+	return tokens
+}
+
+func TestTokenizerEncodePiece(t *testing.T) {
+	ranks := testRanks("ab", "ba", "aa", "bb", "aab", "abab", "bab", "aaaa", "abba", "baab")
+	tokenizer, err := NewTokenizer(EncodingCL100kBase, ranks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	random := rand.New(rand.NewPCG(1, 2))
+	for range 1000 {
+		piece := make([]byte, 1+random.IntN(40))
+		for i := range piece {
+			piece[i] = "ab"[random.IntN(2)]
+		}
+
+		expected := referenceEncodePiece(ranks, string(piece))
+		if tokens := tokenizer.encodePiece(string(piece), nil); !reflect.DeepEqual(tokens, expected) {
+			t.Fatalf("piece %q: expected %v, got %v", piece, expected, tokens)
+		}
+	}
+
+	// long unbroken piece is encoded without quadratic rescans
+	long := strings.Repeat("ab", 100_000)
+	if n := len(tokenizer.encodePiece(long, nil)); n != 50_000 {
+		t.Errorf("unexpected tokens number %d", n)
+	}
+}
+
+func TestReadEncoding(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		ranks = testRanks("he", "ll")
+	)
+
+	w := gzip.NewWriter(&buf)
+	for token, rank := range ranks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"encodings/o200k_base.tiktoken.gz":  {Data: buf.Bytes()},
+		"encodings/cl100k_base.tiktoken.gz": {Data: []byte("not gzip")},
+	}
+
+	tokenizer, err := readEncoding(fsys, EncodingO200kBase)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tokens := tokenizer.Encode("hell"); !reflect.DeepEqual(tokens, []int{256, 257}) {
+		t.Errorf("unexpected tokens %v", tokens)
+	}
+
+	if _, err = readEncoding(fsys, EncodingCL100kBase); err == nil {
+		t.Error("expected error")
+	}
+
+	if _, err = readEncoding(fstest.MapFS{}, EncodingCL100kBase); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v, got %v", fs.ErrNotExist, err)
+	}
+}
+
+// TestEmbeddedTokenizers checks the embedded encodings against tiktoken outputs.
+func TestEmbeddedTokenizers(t *testing.T) {
+	testCases := []struct {
+		encoding TokenEncoding
+		text     string
+		expected []int
+	}{
+		{encoding: EncodingCL100kBase, text: "hello world", expected: []int{15339, 1917}},
+		{encoding: EncodingCL100kBase, text: "tiktoken is great!", expected: []int{83, 1609, 5963, 374, 2294, 0}},
+		{encoding: EncodingO200kBase, text: "hello world", expected: []int{24912, 2375}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(string(tc.encoding)+"/"+tc.text, func(t *testing.T) {
+			tokenizer, err := embeddedTokenizers[tc.encoding]()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tokens := tokenizer.Encode(tc.text); !reflect.DeepEqual(tokens, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, tokens)
+			}
+		})
+	}
+}
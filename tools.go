@@ -0,0 +1,155 @@
+package aoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ToolType is a type of tool.
+type ToolType string
+
+// Tool types.
+const (
+	ToolTypeFunction ToolType = "function"
+)
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t *ToolType) MarshalJSON() ([]byte, error) {
+	return marshalJSON(t, ToolTypeFunction)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *ToolType) UnmarshalJSON(b []byte) error {
+	return unMarshalJSON(t, b, ToolTypeFunction)
+}
+
+// Function is a struct of function description which the model may call.
+// Parameters is a JSON schema object of the function arguments.
+type Function struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// Tool is a struct of tool which the model may call.
+type Tool struct {
+	Type     ToolType `json:"type"`
+	Function Function `json:"function"`
+}
+
+// FunctionCall is a struct of function name and arguments generated by the model.
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// Unmarshal decodes JSON function arguments to v.
+func (f *FunctionCall) Unmarshal(v any) error {
+	if err := json.Unmarshal([]byte(f.Arguments), v); err != nil {
+		return errors.Join(ErrResponse, fmt.Errorf("failed to unmarshal arguments of %q: %w", f.Name, err))
+	}
+
+	return nil
+}
+
+// ToolCall is a struct of tool call generated by the model.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     ToolType     `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// ToolCallDelta is a struct of partial tool call from the streamed chunk.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     ToolType     `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// ToolChoiceMode is a type of tool choice mode.
+type ToolChoiceMode string
+
+// Tool choice modes.
+const (
+	ToolChoiceNone     ToolChoiceMode = "none"
+	ToolChoiceAuto     ToolChoiceMode = "auto"
+	ToolChoiceRequired ToolChoiceMode = "required"
+)
+
+// ToolChoice controls which tool is called by the model.
+// If Function is not empty, the model is forced to call this function, and Mode is ignored.
+type ToolChoice struct {
+	Mode     ToolChoiceMode
+	Function string
+}
+
+// toolChoiceFunction is a JSON representation of the forced function tool choice.
+type toolChoiceFunction struct {
+	Type     ToolType `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (tc *ToolChoice) MarshalJSON() ([]byte, error) {
+	if tc.Function != "" {
+		v := toolChoiceFunction{Type: ToolTypeFunction}
+		v.Function.Name = tc.Function
+		return json.Marshal(&v)
+	}
+
+	switch tc.Mode {
+	case ToolChoiceNone, ToolChoiceAuto, ToolChoiceRequired:
+		return json.Marshal(string(tc.Mode))
+	}
+
+	return nil, errors.Join(ErrMarshalJSON, fmt.Errorf("invalid tool choice mode: %v", tc.Mode))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (tc *ToolChoice) UnmarshalJSON(b []byte) error {
+	var mode string
+
+	if err := json.Unmarshal(b, &mode); err == nil {
+		switch m := ToolChoiceMode(mode); m {
+		case ToolChoiceNone, ToolChoiceAuto, ToolChoiceRequired:
+			*tc = ToolChoice{Mode: m}
+			return nil
+		}
+
+		return errors.Join(ErrUnmarshalJSON, fmt.Errorf("invalid tool choice mode: %v", mode))
+	}
+
+	v := toolChoiceFunction{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return errors.Join(ErrUnmarshalJSON, fmt.Errorf("invalid tool choice: %w", err))
+	}
+
+	*tc = ToolChoice{Function: v.Function.Name}
+	return nil
+}
+
+// validateTools checks the tools and tool messages of the request.
+func (c *CompletionRequest) validateTools() error {
+	for i := range c.Tools {
+		if c.Tools[i].Function.Name == "" {
+			return errors.Join(ErrRequiredParam, fmt.Errorf("tool %d function name must not be empty", i))
+		}
+	}
+
+	if c.ToolChoice != nil && c.ToolChoice.Function != "" && len(c.Tools) == 0 {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("tools must not be empty for function tool choice"))
+	}
+
+	for i := range c.Messages {
+		if c.Messages[i].Role == RoleTool && c.Messages[i].ToolCallID == "" {
+			return errors.Join(ErrRequiredParam, fmt.Errorf("message %d tool call id must not be empty", i))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package aoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToolChoice_MarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		choice   ToolChoice
+		expected string
+		err      error
+	}{
+		{
+			name:     "auto",
+			choice:   ToolChoice{Mode: ToolChoiceAuto},
+			expected: `"auto"`,
+		},
+		{
+			name:     "none",
+			choice:   ToolChoice{Mode: ToolChoiceNone},
+			expected: `"none"`,
+		},
+		{
+			name:     "required",
+			choice:   ToolChoice{Mode: ToolChoiceRequired},
+			expected: `"required"`,
+		},
+		{
+			name:     "function",
+			choice:   ToolChoice{Mode: ToolChoiceAuto, Function: "get_weather"},
+			expected: `{"type":"function","function":{"name":"get_weather"}}`,
+		},
+		{
+			name:   "unknown",
+			choice: ToolChoice{Mode: "unknown"},
+			err:    ErrMarshalJSON,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.choice.MarshalJSON()
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error: %v, got: %v", tc.err, err)
+				}
+				return
+			}
+
+			if tc.err != nil {
+				t.Fatalf("expected error, but got nil")
+			}
+
+			if s := string(data); s != tc.expected {
+				t.Fatalf("expected: %q, got: %q", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestToolChoice_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected ToolChoice
+		err      error
+	}{
+		{
+			name:     "auto",
+			data:     `"auto"`,
+			expected: ToolChoice{Mode: ToolChoiceAuto},
+		},
+		{
+			name:     "required",
+			data:     `"required"`,
+			expected: ToolChoice{Mode: ToolChoiceRequired},
+		},
+		{
+			name:     "function",
+			data:     `{"type":"function","function":{"name":"get_weather"}}`,
+			expected: ToolChoice{Function: "get_weather"},
+		},
+		{
+			name: "unknown",
+			data: `"unknown"`,
+			err:  ErrUnmarshalJSON,
+		},
+		{
+			name: "invalid",
+			data: `[1]`,
+			err:  ErrUnmarshalJSON,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var choice ToolChoice
+			err := choice.UnmarshalJSON([]byte(tc.data))
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error: %v, got: %v", tc.err, err)
+				}
+				return
+			}
+
+			if tc.err != nil {
+				t.Fatalf("expected error, but got nil")
+			}
+
+			if choice != tc.expected {
+				t.Fatalf("expected: %v, got: %v", tc.expected, choice)
+			}
+		})
+	}
+}
+
+func TestCompletionRequestTools(t *testing.T) {
+	var parallel = false
+
+	testCases := []struct {
+		name      string
+		request   CompletionRequest
+		errString string
+		expected  []string
+	}{
+		{
+			name: "tools",
+			request: CompletionRequest{
+				Model:    ModelGPT4o,
+				Messages: []Message{{Role: RoleUser, Content: "What is the weather in Paris?"}},
+				Tools: []Tool{
+					{
+						Type: ToolTypeFunction,
+						Function: Function{
+							Name:        "get_weather",
+							Description: "Get weather by city",
+							Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+						},
+					},
+				},
+				ToolChoice:        &ToolChoice{Mode: ToolChoiceRequired},
+				ParallelToolCalls: &parallel,
+			},
+			expected: []string{
+				`"tools":[{"type":"function","function":{"name":"get_weather","description":"Get weather by city",` +
+					`"parameters":{"type":"object","properties":{"city":{"type":"string"}}}}}]`,
+				`"tool_choice":"required"`,
+				`"parallel_tool_calls":false`,
+			},
+		},
+		{
+			name: "tool messages",
+			request: CompletionRequest{
+				Model: ModelGPT4o,
+				Messages: []Message{
+					{Role: RoleUser, Content: "What is the weather in Paris?"},
+					{
+						Role: RoleAssistant,
+						ToolCalls: []ToolCall{
+							{
+								ID:       "call_1",
+								Type:     ToolTypeFunction,
+								Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+							},
+						},
+					},
+					{Role: RoleTool, Content: "sunny", ToolCallID: "call_1"},
+				},
+			},
+			expected: []string{
+				`"tool_calls":[{"id":"call_1","type":"function",` +
+					`"function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]`,
+				`{"role":"tool","content":"sunny","tool_call_id":"call_1"}`,
+			},
+		},
+		{
+			name: "empty function name",
+			request: CompletionRequest{
+				Model:    ModelGPT4o,
+				Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+				Tools:    []Tool{{Type: ToolTypeFunction}},
+			},
+			errString: "tool 0 function name must not be empty",
+		},
+		{
+			name: "function choice without tools",
+			request: CompletionRequest{
+				Model:      ModelGPT4o,
+				Messages:   []Message{{Role: RoleUser, Content: "Hello"}},
+				ToolChoice: &ToolChoice{Function: "get_weather"},
+			},
+			errString: "tools must not be empty for function tool choice",
+		},
+		{
+			name: "tool message without id",
+			request: CompletionRequest{
+				Model:    ModelGPT4o,
+				Messages: []Message{{Role: RoleTool, Content: "sunny"}},
+			},
+			errString: "message 0 tool call id must not be empty",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := tc.request.marshal()
+			if err != nil {
+				if tc.errString == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !errors.Is(err, ErrRequiredParam) {
+					t.Errorf("expected %v, got %v", ErrRequiredParam, err)
+				}
+
+				if e := err.Error(); !strings.Contains(e, tc.errString) {
+					t.Errorf("expected %q, got %q", tc.errString, e)
+				}
+				return
+			}
+
+			if tc.errString != "" {
+				t.Fatalf("expected error %q", tc.errString)
+			}
+
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, expected := range tc.expected {
+				if s := string(data); !strings.Contains(s, expected) {
+					t.Errorf("expected %q to contain %q", s, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCompletionToolCalls(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"id":"test","object":"chat.completion","created":1677652288,` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[` +
+			`{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},` +
+			`"finish_reason":"tool_calls"}],` +
+			`"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Model:    ModelGPT4o,
+		Messages: []Message{{Role: RoleUser, Content: "What is the weather in Paris?"}},
+		Tools:    []Tool{{Type: ToolTypeFunction, Function: Function{Name: "get_weather"}}},
+	}
+
+	response, err := Completion(context.Background(), s.Client(), request, Params{Bearer: "test", URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	choice := response.Choices[0]
+	if choice.FinishReason != FinishReasonToolCalls {
+		t.Errorf("unexpected finish reason: %q", choice.FinishReason)
+	}
+
+	if n := len(choice.Message.ToolCalls); n != 1 {
+		t.Fatalf("unexpected tool calls number: %d", n)
+	}
+
+	var args struct {
+		City string `json:"city"`
+	}
+
+	call := choice.Message.ToolCalls[0]
+	if err = call.Function.Unmarshal(&args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if call.ID != "call_1" || call.Function.Name != "get_weather" || args.City != "Paris" {
+		t.Errorf("unexpected tool call: %#v", call)
+	}
+
+	call.Function.Arguments = "{"
+	if err = call.Function.Unmarshal(&args); !errors.Is(err, ErrResponse) {
+		t.Errorf("expected %v, got %v", ErrResponse, err)
+	}
+}
+
+func TestCompletionStreamToolCalls(t *testing.T) {
+	body := `data: {"id":"test","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[` +
+		`{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"id":"test","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}
+
+data: {"id":"test","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}
+
+data: {"id":"test","choices":[{"index":0,"delta":{"tool_calls":[` +
+		`{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}
+
+data: {"id":"test","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+	s := streamServer(t, body)
+	defer s.Close()
+
+	request := &CompletionRequest{
+		Model:    ModelGPT4o,
+		Messages: []Message{{Role: RoleUser, Content: "What is the weather in Paris?"}},
+	}
+
+	stream, err := CompletionStream(context.Background(), s.Client(), request, Params{URL: s.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response, err := stream.Response()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ToolCall{
+		{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		{ID: "call_2", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+
+	calls := response.Choices[0].Message.ToolCalls
+	if len(calls) != len(expected) {
+		t.Fatalf("unexpected tool calls: %#v", calls)
+	}
+
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("expected %#v, got %#v", expected[i], calls[i])
+		}
+	}
+
+	if fr := response.Choices[0].FinishReason; fr != FinishReasonToolCalls {
+		t.Errorf("unexpected finish reason: %q", fr)
+	}
+}
@@ -20,18 +20,25 @@ type Role string
 // User message roles.
 const (
 	RoleSystem    Role = "system"
+	RoleDeveloper Role = "developer" // system instructions of reasoning models
 	RoleUser      Role = "user"
 	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
 )
 
 // MarshalJSON implements the json.Marshaler interface.
 func (r *Role) MarshalJSON() ([]byte, error) {
-	return marshalJSON(r, RoleSystem, RoleUser, RoleAssistant)
+	return marshalJSON(r, RoleSystem, RoleDeveloper, RoleUser, RoleAssistant, RoleTool)
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (r *Role) UnmarshalJSON(b []byte) error {
-	return unMarshalJSON(r, b, RoleSystem, RoleUser, RoleAssistant)
+	return unMarshalJSON(r, b, RoleSystem, RoleDeveloper, RoleUser, RoleAssistant, RoleTool)
+}
+
+// isInstruction returns true for system and developer roles.
+func (r Role) isInstruction() bool {
+	return r == RoleSystem || r == RoleDeveloper
 }
 
 // Model is a type of AI model name.
@@ -39,17 +46,18 @@ type Model string
 
 // AI model names.
 const (
-	ModelDalle2           Model = "dall-e-2" // only for image requests
-	ModelDalle3           Model = "dall-e-3" // only for image requests
+	ModelDalle2           Model = "dall-e-2"    // only for image requests
+	ModelDalle3           Model = "dall-e-3"    // only for image requests
+	ModelGPTImage1        Model = "gpt-image-1" // only for image requests
 	ModelGPT35Turbo       Model = "gpt-3.5-turbo"
 	ModelGPT4             Model = "gpt-4"
 	ModelGPT4Turbo        Model = "gpt-4-turbo"
 	ModelGPT4o            Model = "gpt-4o"
 	ModelGPT4oTurbo       Model = "gpt-4o-turbo"
 	ModelGPT4oMini        Model = "gpt-4o-mini"
-	ModelGPT41            Model = "gpt-4.1"      // only for image requests
-	ModelGPT41Mini        Model = "gpt-4.1-mini" // only for image requests
-	ModelGPT41Nano        Model = "gpt-4.1-nano" // only for image requests
+	ModelGPT41            Model = "gpt-4.1"
+	ModelGPT41Mini        Model = "gpt-4.1-mini"
+	ModelGPT41Nano        Model = "gpt-4.1-nano"
 	ModelGPT45Preview     Model = "gpt-4.5-preview"
 	ModelGPT5             Model = "gpt-5"
 	ModelGPT5Mini         Model = "gpt-5-mini"
@@ -63,35 +71,51 @@ const (
 	ModelCodexMiniLatest  Model = "codex-mini-latest"
 	ModelDeepSeekChat     Model = "deepseek-chat"     // DeepSeek base model
 	ModelDeepSeekReasoner Model = "deepseek-reasoner" // DeepSeek model with reasoning
-)
 
-// all models for image generation
-var imageModels = map[Model]struct{}{ModelDalle2: {}, ModelDalle3: {}}
+	ModelClaudeOpus41  Model = "claude-opus-4-1"         // only with Anthropic provider
+	ModelClaudeSonnet4 Model = "claude-sonnet-4-0"       // only with Anthropic provider
+	ModelClaude35Haiku Model = "claude-3-5-haiku-latest" // only with Anthropic provider
+
+	ModelGemini25Pro   Model = "gemini-2.5-pro"   // only with Gemini provider
+	ModelGemini25Flash Model = "gemini-2.5-flash" // only with Gemini provider
+
+	ModelTextEmbedding3Small Model = "text-embedding-3-small" // only for embedding requests
+	ModelTextEmbedding3Large Model = "text-embedding-3-large" // only for embedding requests
+	ModelTextEmbeddingAda002 Model = "text-embedding-ada-002" // only for embedding requests
+
+	ModelWhisper1            Model = "whisper-1"              // only for audio transcription and translation requests
+	ModelGPT4oTranscribe     Model = "gpt-4o-transcribe"      // only for audio transcription requests
+	ModelGPT4oMiniTranscribe Model = "gpt-4o-mini-transcribe" // only for audio transcription requests
+
+	ModelTTS1         Model = "tts-1"           // only for speech requests
+	ModelTTS1HD       Model = "tts-1-hd"        // only for speech requests
+	ModelGPT4oMiniTTS Model = "gpt-4o-mini-tts" // only for speech requests
+)
 
 // MarshalJSON implements the json.Marshaler interface.
+// Any non-empty model name is allowed, see RegisterModel to describe unknown models.
 func (m *Model) MarshalJSON() ([]byte, error) {
-	return marshalJSON(
-		m,
-		ModelDalle2, ModelDalle3,
-		ModelGPT35Turbo, ModelGPT4, ModelGPT4Turbo, ModelGPT4o, ModelGPT4oTurbo, ModelGPT4oMini, ModelGPT45Preview,
-		ModelGPTo1, ModelGPTo1Pro, ModelGPTo1Mini, ModelGPTo1Preview, ModelGPTo3Mini,
-		ModelGPT41, ModelGPT41Mini, ModelGPT41Nano, ModelCodexMiniLatest,
-		ModelGPT5, ModelGPT5Mini, ModelGPT5Nano, ModelGPT5ChatLatest,
-		ModelDeepSeekChat, ModelDeepSeekReasoner,
-	)
+	if *m == "" {
+		return nil, errors.Join(ErrMarshalJSON, fmt.Errorf("empty model name"))
+	}
+
+	return json.Marshal(string(*m))
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (m *Model) UnmarshalJSON(b []byte) error {
-	return unMarshalJSON(
-		m, b,
-		ModelDalle2, ModelDalle3,
-		ModelGPT35Turbo, ModelGPT4, ModelGPT4Turbo, ModelGPT4o, ModelGPT4oTurbo, ModelGPT4oMini, ModelGPT45Preview,
-		ModelGPTo1, ModelGPTo1Pro, ModelGPTo1Mini, ModelGPTo1Preview, ModelGPTo3Mini,
-		ModelGPT41, ModelGPT41Mini, ModelGPT41Nano, ModelCodexMiniLatest,
-		ModelGPT5, ModelGPT5Mini, ModelGPT5Nano, ModelGPT5ChatLatest,
-		ModelDeepSeekChat, ModelDeepSeekReasoner,
-	)
+	var s string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Join(ErrUnmarshalJSON, fmt.Errorf("invalid string value: %v", string(b)))
+	}
+
+	if s == "" {
+		return errors.Join(ErrUnmarshalJSON, fmt.Errorf("empty model name"))
+	}
+
+	*m = Model(s)
+	return nil
 }
 
 // FinishReason is a type of response finish reason.
@@ -99,23 +123,34 @@ type FinishReason string
 
 // Finish reasons variants.
 const (
-	FinishReasonLength FinishReason = "length"
-	FinishReasonStop   FinishReason = "stop"
+	FinishReasonLength        FinishReason = "length"
+	FinishReasonStop          FinishReason = "stop"
+	FinishReasonToolCalls     FinishReason = "tool_calls"
+	FinishReasonContentFilter FinishReason = "content_filter"
+	// FinishReasonInsufficientSystemResource is returned by DeepSeek API if the generation is interrupted by overload.
+	FinishReasonInsufficientSystemResource FinishReason = "insufficient_system_resource"
 )
 
+var finishReasons = []FinishReason{
+	FinishReasonLength, FinishReasonStop, FinishReasonToolCalls, FinishReasonContentFilter,
+	FinishReasonInsufficientSystemResource,
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 func (f *FinishReason) MarshalJSON() ([]byte, error) {
-	return marshalJSON(f, FinishReasonLength, FinishReasonStop)
+	return marshalJSON(f, finishReasons...)
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (f *FinishReason) UnmarshalJSON(b []byte) error {
-	return unMarshalJSON(f, b, FinishReasonLength, FinishReasonStop)
+	return unMarshalJSON(f, b, finishReasons...)
 }
 
 // StringCommonType is a generic interface for custom string based types.
+// Model is kept for compatibility, it is not restricted by a list of values anymore.
 type StringCommonType interface {
-	Role | Model | FinishReason
+	Role | Model | FinishReason | ToolType | ResponseFormatType | ContentPartType | EncodingFormat |
+		ReasoningEffort | Verbosity
 }
 
 // marshalJSON is a generic function for custom types JSON marshal.
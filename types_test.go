@@ -17,6 +17,11 @@ func TestRole_MarshalJSON(t *testing.T) {
 			role:     RoleSystem,
 			expected: `"system"`,
 		},
+		{
+			name:     "developer",
+			role:     RoleDeveloper,
+			expected: `"developer"`,
+		},
 		{
 			name:     "user",
 			role:     RoleUser,
@@ -27,6 +32,11 @@ func TestRole_MarshalJSON(t *testing.T) {
 			role:     RoleAssistant,
 			expected: `"assistant"`,
 		},
+		{
+			name:     "tool",
+			role:     RoleTool,
+			expected: `"tool"`,
+		},
 		{
 			name: "unknown",
 			role: Role("unknown"),
@@ -78,6 +88,11 @@ func TestRole_UnmarshalJSON(t *testing.T) {
 			data:     `"assistant"`,
 			expected: RoleAssistant,
 		},
+		{
+			name:     "tool",
+			data:     `"tool"`,
+			expected: RoleTool,
+		},
 		{
 			name: "unknown",
 			data: `"unknown"`,
@@ -216,8 +231,13 @@ func TestModel_MarshalJSON(t *testing.T) {
 			expected: `"codex-mini-latest"`,
 		},
 		{
-			name:  "unknown",
-			model: Model("unknown"),
+			name:     "custom",
+			model:    Model("gpt-4o-2024-08-06"),
+			expected: `"gpt-4o-2024-08-06"`,
+		},
+		{
+			name:  "empty",
+			model: Model(""),
 			err:   ErrMarshalJSON,
 		},
 	}
@@ -245,6 +265,20 @@ func TestModel_MarshalJSON(t *testing.T) {
 	}
 }
 
+func TestModel_StringCommonType(t *testing.T) {
+	// Model is still a part of the exported constraint
+	model := ModelGPT4oMini
+
+	data, err := marshalJSON(&model, ModelGPT4oMini)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(data); s != `"gpt-4o-mini"` {
+		t.Errorf("unexpected data %q", s)
+	}
+}
+
 func TestModel_UnmarshalJSON(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -353,8 +387,18 @@ func TestModel_UnmarshalJSON(t *testing.T) {
 			expected: ModelCodexMiniLatest,
 		},
 		{
-			name: "unknown",
-			data: `"unknown"`,
+			name:     "custom",
+			data:     `"llama3.1:8b"`,
+			expected: Model("llama3.1:8b"),
+		},
+		{
+			name: "empty",
+			data: `""`,
+			err:  ErrUnmarshalJSON,
+		},
+		{
+			name: "invalid",
+			data: `1`,
 			err:  ErrUnmarshalJSON,
 		},
 	}
@@ -380,8 +424,16 @@ func TestModel_UnmarshalJSON(t *testing.T) {
 				t.Fatalf("expected: %v, got: %v", tc.expected, model)
 			}
 
-			_, isImage := imageModels[model]
-			if _, ok := TokenLimits[model]; !(ok || isImage) {
+			if tc.name == "custom" {
+				return
+			}
+
+			info, ok := LookupModel(model)
+			if !ok {
+				t.Fatalf("model %v is not registered", model)
+			}
+
+			if !info.Capabilities.Has(CapabilityImage) && info.MaxTokens == 0 {
 				t.Errorf("model %v has no token limit", model)
 			}
 		})
@@ -405,6 +457,11 @@ func TestFinishReason_MarshalJSON(t *testing.T) {
 			reason:   FinishReasonStop,
 			expected: `"stop"`,
 		},
+		{
+			name:     "tool_calls",
+			reason:   FinishReasonToolCalls,
+			expected: `"tool_calls"`,
+		},
 		{
 			name:   "unknown",
 			reason: FinishReason("unknown"),
@@ -451,6 +508,16 @@ func TestFinishReason_UnmarshalJSON(t *testing.T) {
 			data:     `"stop"`,
 			expected: FinishReasonStop,
 		},
+		{
+			name:     "tool_calls",
+			data:     `"tool_calls"`,
+			expected: FinishReasonToolCalls,
+		},
+		{
+			name:     "insufficient_system_resource",
+			data:     `"insufficient_system_resource"`,
+			expected: FinishReasonInsufficientSystemResource,
+		},
 		{
 			name: "unknown",
 			data: `"unknown"`,
@@ -0,0 +1,117 @@
+package aoapi
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PromptTokensDetails is a breakdown of the prompt tokens.
+type PromptTokensDetails struct {
+	CachedTokens uint `json:"cached_tokens"`
+	AudioTokens  uint `json:"audio_tokens"`
+}
+
+// CompletionTokensDetails is a breakdown of the completion tokens.
+// Prediction tokens are counted only for requests with predicted outputs.
+type CompletionTokensDetails struct {
+	ReasoningTokens          uint `json:"reasoning_tokens"`
+	AudioTokens              uint `json:"audio_tokens"`
+	AcceptedPredictionTokens uint `json:"accepted_prediction_tokens"`
+	RejectedPredictionTokens uint `json:"rejected_prediction_tokens"`
+}
+
+// Add returns the sum of two usages.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+		PromptTokensDetails: PromptTokensDetails{
+			CachedTokens: u.PromptTokensDetails.CachedTokens + other.PromptTokensDetails.CachedTokens,
+			AudioTokens:  u.PromptTokensDetails.AudioTokens + other.PromptTokensDetails.AudioTokens,
+		},
+		CompletionTokensDetails: CompletionTokensDetails{
+			ReasoningTokens: u.CompletionTokensDetails.ReasoningTokens + other.CompletionTokensDetails.ReasoningTokens,
+			AudioTokens:     u.CompletionTokensDetails.AudioTokens + other.CompletionTokensDetails.AudioTokens,
+			AcceptedPredictionTokens: u.CompletionTokensDetails.AcceptedPredictionTokens +
+				other.CompletionTokensDetails.AcceptedPredictionTokens,
+			RejectedPredictionTokens: u.CompletionTokensDetails.RejectedPredictionTokens +
+				other.CompletionTokensDetails.RejectedPredictionTokens,
+		},
+		PromptCacheHitTokens:  u.PromptCacheHitTokens + other.PromptCacheHitTokens,
+		PromptCacheMissTokens: u.PromptCacheMissTokens + other.PromptCacheMissTokens,
+	}
+}
+
+// String returns tokens usage information, zero details are skipped.
+func (u Usage) String() string {
+	var builder strings.Builder
+
+	_, _ = fmt.Fprintf(&builder, "prompt tokens: %d, completion tokens: %d, total tokens: %d",
+		u.PromptTokens, u.CompletionTokens, u.TotalTokens,
+	)
+
+	details := []struct {
+		name  string
+		value uint
+	}{
+		{name: "cached tokens", value: u.PromptTokensDetails.CachedTokens},
+		{name: "prompt audio tokens", value: u.PromptTokensDetails.AudioTokens},
+		{name: "reasoning tokens", value: u.CompletionTokensDetails.ReasoningTokens},
+		{name: "completion audio tokens", value: u.CompletionTokensDetails.AudioTokens},
+		{name: "accepted prediction tokens", value: u.CompletionTokensDetails.AcceptedPredictionTokens},
+		{name: "rejected prediction tokens", value: u.CompletionTokensDetails.RejectedPredictionTokens},
+	}
+
+	for _, detail := range details {
+		if detail.value > 0 {
+			_, _ = fmt.Fprintf(&builder, ", %s: %d", detail.name, detail.value)
+		}
+	}
+
+	return builder.String()
+}
+
+// UsageAggregator sums tokens usage of many responses.
+// It is safe for concurrent use, the zero value is ready to use.
+type UsageAggregator struct {
+	mu       sync.Mutex
+	usage    Usage
+	requests uint
+}
+
+// Add adds the usage to the total.
+func (a *UsageAggregator) Add(usage Usage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.usage = a.usage.Add(usage)
+	a.requests++
+}
+
+// AddResponse adds the response usage to the total, nil response is ignored.
+func (a *UsageAggregator) AddResponse(r *CompletionResponse) {
+	if r != nil {
+		a.Add(r.Usage)
+	}
+}
+
+// Usage returns the total usage and the number of added usages.
+func (a *UsageAggregator) Usage() (Usage, uint) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.usage, a.requests
+}
+
+// Reset returns the total usage and the number of added usages, then resets them.
+func (a *UsageAggregator) Reset() (Usage, uint) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, requests := a.usage, a.requests
+	a.usage, a.requests = Usage{}, 0
+
+	return usage, requests
+}
@@ -0,0 +1,136 @@
+package aoapi
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestUsageUnmarshal(t *testing.T) {
+	data := `{"prompt_tokens":20,"completion_tokens":30,"total_tokens":50,` +
+		`"prompt_tokens_details":{"cached_tokens":10,"audio_tokens":2},` +
+		`"completion_tokens_details":{"reasoning_tokens":12,"audio_tokens":3,` +
+		`"accepted_prediction_tokens":4,"rejected_prediction_tokens":5}}`
+
+	var usage Usage
+	if err := json.Unmarshal([]byte(data), &usage); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Usage{
+		PromptTokens:        20,
+		CompletionTokens:    30,
+		TotalTokens:         50,
+		PromptTokensDetails: PromptTokensDetails{CachedTokens: 10, AudioTokens: 2},
+		CompletionTokensDetails: CompletionTokensDetails{
+			ReasoningTokens:          12,
+			AudioTokens:              3,
+			AcceptedPredictionTokens: 4,
+			RejectedPredictionTokens: 5,
+		},
+	}
+
+	if usage != expected {
+		t.Errorf("expected %#v, got %#v", expected, usage)
+	}
+
+	info := "prompt tokens: 20, completion tokens: 30, total tokens: 50, cached tokens: 10, " +
+		"prompt audio tokens: 2, reasoning tokens: 12, completion audio tokens: 3, " +
+		"accepted prediction tokens: 4, rejected prediction tokens: 5"
+	if s := usage.String(); s != info {
+		t.Errorf("expected %q, got %q", info, s)
+	}
+
+	// zero details are not marshaled
+	b, err := json.Marshal(Usage{PromptTokens: 1, TotalTokens: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := string(b); s != `{"prompt_tokens":1,"completion_tokens":0,"total_tokens":1}` {
+		t.Errorf("unexpected usage %s", s)
+	}
+}
+
+func TestUsageString(t *testing.T) {
+	testCases := []struct {
+		name     string
+		usage    Usage
+		expected string
+	}{
+		{
+			name:     "empty",
+			expected: "prompt tokens: 0, completion tokens: 0, total tokens: 0",
+		},
+		{
+			name: "reasoning",
+			usage: Usage{
+				PromptTokens:            4,
+				CompletionTokens:        6,
+				TotalTokens:             10,
+				CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 5},
+			},
+			expected: "prompt tokens: 4, completion tokens: 6, total tokens: 10, reasoning tokens: 5",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if s := tc.usage.String(); s != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestUsageAggregator(t *testing.T) {
+	const n = 100
+
+	var (
+		aggregator UsageAggregator
+		wg         sync.WaitGroup
+	)
+
+	usage := Usage{
+		PromptTokens:            3,
+		CompletionTokens:        2,
+		TotalTokens:             5,
+		PromptTokensDetails:     PromptTokensDetails{CachedTokens: 1},
+		CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: 1, RejectedPredictionTokens: 1},
+		PromptCacheHitTokens:    1,
+	}
+
+	wg.Add(n)
+	for range n {
+		go func() {
+			defer wg.Done()
+			aggregator.AddResponse(&CompletionResponse{Usage: usage})
+		}()
+	}
+
+	wg.Wait()
+	aggregator.AddResponse(nil)
+
+	expected := Usage{
+		PromptTokens:            3 * n,
+		CompletionTokens:        2 * n,
+		TotalTokens:             5 * n,
+		PromptTokensDetails:     PromptTokensDetails{CachedTokens: n},
+		CompletionTokensDetails: CompletionTokensDetails{ReasoningTokens: n, RejectedPredictionTokens: n},
+		PromptCacheHitTokens:    n,
+	}
+
+	total, requests := aggregator.Usage()
+	if total != expected || requests != n {
+		t.Errorf("expected %v (%d), got %v (%d)", expected, n, total, requests)
+	}
+
+	if total, requests = aggregator.Reset(); total != expected || requests != n {
+		t.Errorf("expected %v (%d), got %v (%d)", expected, n, total, requests)
+	}
+
+	if total, requests = aggregator.Usage(); total != (Usage{}) || requests != 0 {
+		t.Errorf("unexpected usage after reset %v (%d)", total, requests)
+	}
+}